@@ -0,0 +1,66 @@
+// Package main contains a program that parses a consolidated 1099 statement
+// of a brokerage from the pdf2txt XML.
+//
+// Usage:
+//
+//	f1099 -input=<xml_file> [-format=beancount|csv|json] [-payer=Schwab] [-year=2020]
+//
+// The CSV output has the 1099-B lots only.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"os"
+
+	"github.com/filmil/fintools-public/pkg/f1099"
+	"github.com/filmil/fintools-public/pkg/xml"
+	"github.com/golang/glog"
+)
+
+var (
+	input  = flag.String("input", "", "pdf2txt XML file of the consolidated 1099 statement")
+	format = flag.String("format", "beancount", "Output format: beancount, csv or json")
+	payer  = flag.String("payer", "", "Name of the payer, added to the beancount entries")
+	year   = flag.Int("year", 0, "Tax year, if the statement does not say")
+)
+
+func main() {
+	flag.Parse()
+
+	if *input == "" {
+		glog.Fatalf("--input=... is mandatory")
+	}
+	file, err := os.Open(*input)
+	if err != nil {
+		glog.Fatalf("can not open %q: %v", *input, err)
+	}
+	defer file.Close()
+	p, err := xml.Decode(file)
+	if err != nil {
+		glog.Fatalf("xml.Decode(%q)=%v", *input, err)
+	}
+	s, err := f1099.Parse(p)
+	if err != nil {
+		glog.Fatalf("f1099.Parse(%q)=%v", *input, err)
+	}
+	if s.Year == 0 {
+		s.Year = *year
+	}
+
+	switch *format {
+	case "beancount":
+		err = f1099.Beancount(os.Stdout, s, *payer)
+	case "csv":
+		err = f1099.CSV(os.Stdout, s.Lots)
+	case "json":
+		e := json.NewEncoder(os.Stdout)
+		e.SetIndent("", "  ")
+		err = e.Encode(s)
+	default:
+		glog.Fatalf("unknown --format=%q, want beancount, csv or json", *format)
+	}
+	if err != nil {
+		glog.Fatalf("output: %v", err)
+	}
+}
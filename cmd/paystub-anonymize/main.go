@@ -0,0 +1,62 @@
+// Package main contains a program that anonymizes paystub XML files produced
+// by pdf2txt, so that they can be shared and used as test fixtures.
+//
+// Usage:
+//
+//	paystub-anonymize -input=<xml_file> -output=<xml_file> [-scale=3] [-seed=42]
+package main
+
+import (
+	"flag"
+	"os"
+	"strings"
+
+	"github.com/filmil/fintools-public/pkg/anon"
+	"github.com/filmil/fintools-public/pkg/xml"
+	"github.com/golang/glog"
+)
+
+var (
+	input  = flag.String("input", "", "Input filename")
+	output = flag.String("output", "", "Output filename, standard output if empty")
+	scale  = flag.Float64("scale", 3, "All amounts and rates, but not the hours and the vested shares, are multiplied by this; integer values keep all sums exact")
+	seed   = flag.Int64("seed", 0, "Seed for the fake values")
+	keep   = flag.String("keep", "", "Comma separated list of additional labels to keep verbatim")
+)
+
+func main() {
+	flag.Parse()
+
+	if *input == "" {
+		glog.Fatalf("--input=... is mandatory")
+	}
+
+	file, err := os.Open(*input)
+	if err != nil {
+		glog.Fatalf("can not open %q: %v", *input, err)
+	}
+	defer file.Close()
+
+	paystub, err := xml.Decode(file)
+	if err != nil {
+		glog.Fatalf("xml.Decode(%q)=%v", *input, err)
+	}
+
+	o := anon.Options{Scale: *scale, Seed: *seed}
+	if *keep != "" {
+		o.Keep = strings.Split(*keep, ",")
+	}
+	paystub = anon.Anonymize(paystub, o)
+
+	w := os.Stdout
+	if *output != "" {
+		w, err = os.Create(*output)
+		if err != nil {
+			glog.Fatalf("can not create %q: %v", *output, err)
+		}
+		defer w.Close()
+	}
+	if err := xml.Encode(w, paystub); err != nil {
+		glog.Fatalf("xml.Encode(%q)=%v", *output, err)
+	}
+}
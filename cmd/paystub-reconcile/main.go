@@ -0,0 +1,94 @@
+// Package main contains a program that reconciles the W-2 with the paystubs
+// of the year.
+//
+// It computes the W-2 boxes 1, 3, 5, 12 (codes C, D, DD and W) and 17 that
+// the paystubs add up to, and reports the boxes of the W-2 that differ.
+//
+// Usage:
+//
+//	paystub-reconcile -w2=<xml_file> [-year=2020] <paystub_xml_file>...
+//
+// The paystub files may also be transactions in JSON if the name ends with
+// ".json".  Reversal and correction paystubs are linked to the paystubs they
+// adjust first, so that a correction only counts with the change it makes.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/filmil/fintools-public/pkg/tx"
+	"github.com/filmil/fintools-public/pkg/w2"
+	"github.com/filmil/fintools-public/pkg/xml"
+	"github.com/golang/glog"
+)
+
+var (
+	w2File      = flag.String("w2", "", "pdf2txt XML file of the W-2")
+	year        = flag.Int("year", 0, "Tax year of the W-2; the year of the latest paystub if not set")
+	inputFormat = flag.String("input-format", xml.FormatPdfminer, "Format of the paystub files: pdfminer (pdf2txt -t xml), poppler (pdftotext -bbox-layout) or hocr (tesseract)")
+	lenient     = flag.Bool("lenient", false, "If set, line items with unknown labels are skipped instead of failing")
+	stream      = flag.Bool("stream", false, "If set, decodes only the first page of each paystub, without the per-character detail; uses much less memory on large inputs")
+)
+
+func decode(name, format string) (xml.Paystub, error) {
+	file, err := os.Open(name)
+	if err != nil {
+		return xml.Paystub{}, fmt.Errorf("could not open file: %v", err)
+	}
+	defer file.Close()
+	return xml.DecodeFormat(file, format)
+}
+
+// reconcile links the adjustments among the paystubs ts, see
+// tx.LinkAdjustments, and then reconciles the W-2 w with them.  It returns the
+// adjustments that could not be linked, and the boxes that differ.
+func reconcile(w w2.W2, ts []tx.Transaction, year int) ([]string, []w2.Diff) {
+	ws := tx.LinkAdjustments(ts)
+	return ws, w2.Reconcile(w, ts, year)
+}
+
+func main() {
+	flag.Parse()
+
+	if *w2File == "" || flag.NArg() == 0 {
+		fmt.Fprintf(os.Stderr, "flag --w2 and paystub files as arguments are required\n")
+		os.Exit(-1)
+	}
+
+	var ts []tx.Transaction
+	y := *year
+	for _, name := range flag.Args() {
+		t, err := xml.ReadFile(name, *inputFormat, xml.Options{Lenient: *lenient, Stream: *stream})
+		if err != nil {
+			glog.Fatalf("ReadFile: %v: %v", name, err)
+		}
+		if *year == 0 && time.Time(t.Date).Year() > y {
+			y = time.Time(t.Date).Year()
+		}
+		ts = append(ts, t)
+	}
+
+	p, err := decode(*w2File, xml.FormatPdfminer)
+	if err != nil {
+		glog.Fatalf("Decode: %v: %v", *w2File, err)
+	}
+	w, err := w2.Parse(p)
+	if err != nil {
+		glog.Fatalf("Parse: %v: %v", *w2File, err)
+	}
+
+	ws, ds := reconcile(w, ts, y)
+	for _, l := range ws {
+		fmt.Fprintf(os.Stderr, "WARNING: can not link adjustment: %v\n", l)
+	}
+	for _, d := range ds {
+		fmt.Println(d)
+	}
+	if len(ds) > 0 {
+		os.Exit(1)
+	}
+	fmt.Printf("W-2 for %v matches the paystubs\n", y)
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/filmil/fintools-public/pkg/tx"
+	"github.com/filmil/fintools-public/pkg/w2"
+)
+
+func TestReconcileCorrection(t *testing.T) {
+	t.Parallel()
+	original := tx.Transaction{
+		Date:             tx.DateOnly(time.Date(2020, 6, 15, 0, 0, 0, 0, time.UTC)),
+		DocNum:           "1",
+		RegularPay:       1000,
+		FederalIncomeTax: 200,
+	}
+	// The correction restates the full amounts.
+	correction := tx.Transaction{
+		Date:             tx.DateOnly(time.Date(2020, 6, 30, 0, 0, 0, 0, time.UTC)),
+		DocNum:           "2",
+		Kind:             tx.KindCorrection,
+		Adjusts:          "1",
+		RegularPay:       1100,
+		FederalIncomeTax: 220,
+	}
+	w := w2.W2{
+		Wages:               1100,
+		FederalIncomeTax:    220,
+		SocialSecurityWages: 1100,
+		MedicareWages:       1100,
+		StateWages:          1100,
+	}
+	ws, ds := reconcile(w, []tx.Transaction{original, correction}, 2020)
+	if len(ws) != 0 || len(ds) != 0 {
+		t.Errorf("reconcile(_, _, 2020)=%v, %v, want no warnings and no differences", ws, ds)
+	}
+}
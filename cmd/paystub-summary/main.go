@@ -0,0 +1,65 @@
+// Package main contains a program that summarizes paystubs by year, quarter
+// or month: the totals of every earning, deduction, tax and employer
+// contribution, and the gross pay, the taxable wages, the effective tax rates
+// and the 401(k) savings rate.
+//
+// Usage:
+//
+//	paystub-summary [-by=year|quarter|month] [-format=text|csv|json] <file>...
+//
+// The files are paystubs, or transactions in JSON if the name ends with
+// ".json".  Reversal and correction paystubs are linked to the paystubs they
+// adjust first, as in paystub batch mode, so that a correction only counts
+// with the change it makes.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/filmil/fintools-public/pkg/summary"
+	"github.com/filmil/fintools-public/pkg/tx"
+	"github.com/filmil/fintools-public/pkg/xml"
+	"github.com/golang/glog"
+)
+
+var (
+	by          = flag.String("by", summary.ByYear, "Period to summarize by: year, quarter or month")
+	format      = flag.String("format", summary.FormatText, "Output format: text, csv or json")
+	inputFormat = flag.String("input-format", xml.FormatPdfminer, "Format of the paystub files: pdfminer (pdf2txt -t xml), poppler (pdftotext -bbox-layout) or hocr (tesseract)")
+	lenient     = flag.Bool("lenient", false, "If set, line items with unknown labels are summarized under their labels instead of failing")
+	stream      = flag.Bool("stream", false, "If set, decodes only the first page of each paystub, without the per-character detail; uses much less memory on large inputs")
+)
+
+func main() {
+	flag.Parse()
+
+	if flag.NArg() == 0 {
+		fmt.Fprintf(os.Stderr, "input files as arguments are required\n")
+		os.Exit(-1)
+	}
+	var ts []tx.Transaction
+	for _, name := range flag.Args() {
+		t, err := xml.ReadFile(name, *inputFormat, xml.Options{Lenient: *lenient, Stream: *stream})
+		if err != nil {
+			glog.Fatalf("ReadFile: %v: unexpected: %v", name, err)
+		}
+		ts = append(ts, t)
+	}
+	sort.SliceStable(ts, func(i, j int) bool {
+		return time.Time(ts[i].Date).Before(time.Time(ts[j].Date))
+	})
+	for _, w := range tx.LinkAdjustments(ts) {
+		fmt.Fprintf(os.Stderr, "WARNING: can not link adjustment: %v\n", w)
+	}
+	ss, err := summary.Summarize(ts, *by)
+	if err != nil {
+		glog.Fatalf("--by: %v", err)
+	}
+	if err := summary.Write(os.Stdout, ss, *format); err != nil {
+		glog.Fatalf("--format: %v", err)
+	}
+}
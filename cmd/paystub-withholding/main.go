@@ -0,0 +1,84 @@
+// Package main contains a program that projects the income tax withheld on the
+// paystubs of a year to the end of the year, compares it with the federal and
+// the California income tax on the projected wages, and suggests the extra
+// withholding or the estimated payments that reach the safe harbor.
+//
+// Usage:
+//
+//	paystub-withholding [-filing-status=single|married] [-prior-federal-tax=...] [-prior-state-tax=...] [-prior-agi=...] <file>...
+//
+// The files are paystubs, or transactions in JSON if the name ends with
+// ".json".  Reversal and correction paystubs are linked to the paystubs they
+// adjust first, as in paystub batch mode, so that a correction only counts
+// with the change it makes.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/filmil/fintools-public/pkg/tx"
+	"github.com/filmil/fintools-public/pkg/withholding"
+	"github.com/filmil/fintools-public/pkg/xml"
+	"github.com/golang/glog"
+)
+
+var (
+	year            = flag.Int("year", 0, "The year to project; the year of the last paystub if not set")
+	filingStatus    = flag.String("filing-status", withholding.StatusSingle, "Filing status: single or married (filing jointly)")
+	priorFederalTax = flag.Float64("prior-federal-tax", -1, "Total federal income tax of the prior year, for the prior year safe harbor; negative if not known")
+	priorStateTax   = flag.Float64("prior-state-tax", -1, "Total California income tax of the prior year, for the prior year safe harbor; negative if not known")
+	priorAGI        = flag.Float64("prior-agi", -1, "Adjusted gross income of the prior year, which decides between the 100% and the 110% prior year safe harbor; required with a prior year tax")
+	format          = flag.String("format", withholding.FormatText, "Output format: text or json")
+	inputFormat     = flag.String("input-format", xml.FormatPdfminer, "Format of the paystub files: pdfminer (pdf2txt -t xml), poppler (pdftotext -bbox-layout) or hocr (tesseract)")
+	lenient         = flag.Bool("lenient", false, "If set, line items with unknown labels are skipped instead of failing")
+	stream          = flag.Bool("stream", false, "If set, decodes only the first page of each paystub, without the per-character detail; uses much less memory on large inputs")
+)
+
+func main() {
+	flag.Parse()
+
+	if flag.NArg() == 0 {
+		fmt.Fprintf(os.Stderr, "input files as arguments are required\n")
+		os.Exit(-1)
+	}
+	var ts []tx.Transaction
+	y := *year
+	for _, name := range flag.Args() {
+		t, err := xml.ReadFile(name, *inputFormat, xml.Options{Lenient: *lenient, Stream: *stream})
+		if err != nil {
+			glog.Fatalf("ReadFile: %v: unexpected: %v", name, err)
+		}
+		ts = append(ts, t)
+		if *year == 0 && time.Time(t.Date).Year() > y {
+			y = time.Time(t.Date).Year()
+		}
+	}
+	sort.SliceStable(ts, func(i, j int) bool {
+		return time.Time(ts[i].Date).Before(time.Time(ts[j].Date))
+	})
+	for _, w := range tx.LinkAdjustments(ts) {
+		fmt.Fprintf(os.Stderr, "WARNING: can not link adjustment: %v\n", w)
+	}
+	o := withholding.Options{Status: *filingStatus}
+	if *priorFederalTax >= 0 || *priorStateTax >= 0 {
+		if *priorAGI < 0 {
+			glog.Fatalf("--prior-agi is required with --prior-federal-tax or --prior-state-tax")
+		}
+		o.Prior = &withholding.Prior{
+			FederalTax: tx.USD(*priorFederalTax),
+			StateTax:   tx.USD(*priorStateTax),
+			AGI:        tx.USD(*priorAGI),
+		}
+	}
+	p, err := withholding.Project(ts, y, o)
+	if err != nil {
+		glog.Fatalf("Project: %v", err)
+	}
+	if err := withholding.Write(os.Stdout, p, *format); err != nil {
+		glog.Fatalf("--format: %v", err)
+	}
+}
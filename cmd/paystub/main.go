@@ -1,12 +1,47 @@
 // Package main contains the Google paystub analyzer.
+//
+// Usage:
+//
+//	paystub -input=<xml_file>
+//	paystub [flags] <xml_file>...
+//	paystub diff [-format=text|json] <old_xml_file> <new_xml_file>
+//
+// In batch mode, with several input files, the transactions are printed in
+// date order, and changes of the withholding settings between consecutive
+// paystubs are reported on stderr.  Reversal and correction paystubs are
+// linked to the paystubs they adjust.
+//
+// The diff subcommand lines up the line items of two paystubs, and shows how
+// each of them and the header fields changed, see diff.Compare.
+//
+// The withholding of paystubs with bonuses or stock vests is split between
+// the regular and the supplemental wages where the paystubs allow it, and
+// supplemental withholding that is not at the statutory flat rates is
+// reported on stderr, see withholding.Split.
+//
+// Stock vests are output as their own transactions, linked to the paystubs
+// that pay them out.  If the paystubs do not list the vested shares, use
+// -vest-file to read them from vest confirmations, see tx.ReadVests.
+//
+// The files are paystubs, or transactions in JSON if the name ends with
+// ".json".
 package main
 
 import (
 	"flag"
 	"fmt"
+	"io"
 	"os"
+	"sort"
+	"strings"
+	"time"
 
+	"github.com/filmil/fintools-public/pkg/diff"
+	"github.com/filmil/fintools-public/pkg/limits"
 	"github.com/filmil/fintools-public/pkg/out"
+	"github.com/filmil/fintools-public/pkg/tx"
+	"github.com/filmil/fintools-public/pkg/w2"
+	"github.com/filmil/fintools-public/pkg/withholding"
 	"github.com/filmil/fintools-public/pkg/xml"
 	"github.com/golang/glog"
 )
@@ -25,6 +60,11 @@ func t(s string) string {
 
 var (
 	dateOnly = flag.Bool("date-only", false, "If set, prints only the statement date")
+	stream   = flag.Bool("stream", false, "If set, decodes only the first page of the input, without the per-character detail; uses much less memory on large inputs")
+	lenient  = flag.Bool("lenient", false, "If set, line items with unknown labels are booked to the --unknown-* accounts instead of failing the import")
+	vestFile = flag.String("vest-file", "", "JSON file with vest confirmations, for the paystubs that do not list the vested shares and FMV")
+	catchUp  = flag.Bool("catch-up", false, "If set, allows the 401(k) catch-up contributions, from the year of turning 50")
+	otherW2  = flag.String("other-w2", "", "Comma-separated W-2 files from other employers, in --input-format, to check the yearly limits across employers")
 )
 
 func setFlags() {
@@ -50,40 +90,190 @@ func setFlags() {
 	flag.StringVar(&cfg.Vision, "vision", e("Vision"), "")
 	flag.StringVar(&cfg.VolLifeEE, "vol-life-ee", e("VolLifeEe"), "")
 	flag.StringVar(&cfg.VolLifeSpouse, "vol-life-spouse", e("VolLifeSpouse"), "")
+	flag.StringVar(&cfg.HSA, "hsa", e("HSA"), "")
 
 	flag.StringVar(&cfg.FederalIncomeTax, "federal-income-tax", t("FederalIncomeTax"), "")
 	flag.StringVar(&cfg.EmployeeMedicare, "employee-medicare", t("EmployeeMedicare"), "")
 	flag.StringVar(&cfg.SocialSecurityEmployeeTax, "social-security-employee-tax", t("SocialSecurityEmployeeTax"), "")
 	flag.StringVar(&cfg.CAStateIncomeTax, "ca-state-income-tax", t("CaStateIncomeTax"), "")
 	flag.StringVar(&cfg.CAPrivateDisabilityEmployee, "ca-private-disability-employee", t("CAPrivateDisabilityEmployee"), "")
+
+	flag.StringVar(&cfg.UnknownEarnings, "unknown-earnings", "Income:Uncategorized:Paystub", "Account for earnings with unknown labels, see --lenient")
+	flag.StringVar(&cfg.UnknownDeductions, "unknown-deductions", "Expenses:Uncategorized:Paystub", "Account for deductions with unknown labels, see --lenient")
+	flag.StringVar(&cfg.UnknownTaxes, "unknown-taxes", "Expenses:Uncategorized:Paystub:Taxes", "Account for taxes with unknown labels, see --lenient")
+	flag.StringVar(&cfg.UnknownTag, "unknown-tag", "paystub-unknown", "Tag added to transactions with unknown labels, see --lenient")
+
+	flag.StringVar(&cfg.VestAccount, "vest-account", "Assets:Personal:Schwab:GOOG", "Account that holds the vested shares")
+	flag.StringVar(&cfg.VestClearing, "vest-clearing", "Assets:Personal:Google:VestClearing", "Clearing account between the paystub and its vest transactions")
+	flag.StringVar(&cfg.VestSymbol, "vest-symbol", "GOOG", "Commodity of the vested shares, if the vest does not name it")
+
+	flag.StringVar(&cfg.Imputed, "imputed", out.ImputedVisible, "How to output the imputed income, such as group term life: visible, in a tagged transaction of its own, or net, as metadata only")
+	flag.StringVar(&cfg.ImputedTag, "imputed-tag", out.DefaultImputedTag, "Tag of the imputed income transactions, see --imputed")
 }
 
 var (
-	inputFile = flag.String("input", "", "Name of the file to examine")
+	inputFile   = flag.String("input", "", "Name of the file to examine")
+	inputFormat = flag.String("input-format", xml.FormatPdfminer, "Format of the input file: pdfminer (pdf2txt -t xml), poppler (pdftotext -bbox-layout) or hocr (tesseract)")
 
 	cfg out.Config
 )
 
+// readVests reads the vest confirmations from the named file.
+func readVests(name string) ([]tx.Vest, error) {
+	file, err := os.Open(name)
+	if err != nil {
+		return nil, fmt.Errorf("could not open file: %v", err)
+	}
+	defer file.Close()
+	return tx.ReadVests(file)
+}
+
+// readOtherW2 reads the totals of the other employers from the named W-2
+// files.
+func readOtherW2(names string) ([]limits.Other, error) {
+	var r []limits.Other
+	for _, name := range strings.Split(names, ",") {
+		file, err := os.Open(name)
+		if err != nil {
+			return nil, fmt.Errorf("could not open file: %v", err)
+		}
+		p, err := xml.DecodeFormat(file, *inputFormat)
+		file.Close()
+		if err != nil {
+			return nil, fmt.Errorf("%v: Decode: unexpected: %v", name, err)
+		}
+		w, err := w2.Parse(p)
+		if err != nil {
+			return nil, fmt.Errorf("%v: %v", name, err)
+		}
+		if w.Year == 0 {
+			return nil, fmt.Errorf("%v: the tax year is not known", name)
+		}
+		r = append(r, limits.Other{
+			Year:     w.Year,
+			Employer: w.EmployerEIN,
+			Totals: limits.Totals{
+				Elective:          w.Box12[w2.CodeD],
+				SocialSecurityTax: w.SocialSecurityTax,
+				MedicareWages:     w.MedicareWages,
+			},
+		})
+	}
+	return r, nil
+}
+
+// reportW4Changes prints the changes of the withholding settings between
+// consecutive paystubs.
+func reportW4Changes(w io.Writer, ts []tx.Transaction) {
+	for _, c := range tx.W4Changes(ts) {
+		fmt.Fprintf(w, "WARNING: withholding settings changed between %v (%v) and %v (%v):\n",
+			out.YMD(c.Prev.Date), c.Prev.DocNum, out.YMD(c.T.Date), c.T.DocNum)
+		for _, s := range c.Changes {
+			fmt.Fprintf(w, "  %v\n", s)
+		}
+	}
+}
+
+// reportGaps prints the days between the pay periods that no paystub covers.
+func reportGaps(w io.Writer, ts []tx.Transaction) {
+	for _, g := range tx.Gaps(ts) {
+		fmt.Fprintf(w, "WARNING: no paystub covers the days from %v to %v\n", out.YMD(g.Start), out.YMD(g.End))
+	}
+}
+
+// diffMain runs the diff subcommand with its arguments.
+func diffMain(args []string) {
+	fs := flag.NewFlagSet("diff", flag.ExitOnError)
+	format := fs.String("format", diff.FormatText, "Output format: text or json")
+	fs.StringVar(inputFormat, "input-format", *inputFormat, "Format of the input files: pdfminer (pdf2txt -t xml), poppler (pdftotext -bbox-layout) or hocr (tesseract)")
+	fs.BoolVar(lenient, "lenient", *lenient, "If set, line items with unknown labels are compared by their labels instead of failing")
+	fs.Parse(args)
+	if fs.NArg() != 2 {
+		fmt.Fprintf(os.Stderr, "usage: paystub diff [flags] <old_xml_file> <new_xml_file>\n")
+		os.Exit(-1)
+	}
+	var ts []tx.Transaction
+	for _, name := range fs.Args() {
+		t, err := xml.ReadFile(name, *inputFormat, xml.Options{Lenient: *lenient, Stream: *stream})
+		if err != nil {
+			glog.Fatalf("ReadFile: %v: unexpected: %v", name, err)
+		}
+		ts = append(ts, t)
+	}
+	if err := diff.Write(os.Stdout, diff.Compare(ts[0], ts[1]), *format); err != nil {
+		glog.Fatalf("--format: %v", err)
+	}
+}
+
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "diff" {
+		diffMain(os.Args[2:])
+		return
+	}
 	setFlags()
 	flag.Parse()
 
-	if *inputFile == "" {
-		fmt.Fprintf(os.Stderr, "flag --input is required\n")
+	inputs := flag.Args()
+	if *inputFile != "" {
+		inputs = append([]string{*inputFile}, inputs...)
+	}
+	if len(inputs) == 0 {
+		fmt.Fprintf(os.Stderr, "flag --input or input files as arguments are required\n")
 		os.Exit(-1)
 	}
+	if cfg.Imputed != out.ImputedVisible && cfg.Imputed != out.ImputedNet {
+		glog.Fatalf("--imputed: want %v or %v, got %q", out.ImputedVisible, out.ImputedNet, cfg.Imputed)
+	}
 
-	file, err := os.Open(*inputFile)
-	if err != nil {
-		glog.Fatalf("could not open file: %v", err)
+	o := limits.Options{CatchUp: *catchUp}
+	if *otherW2 != "" {
+		var err error
+		if o.Other, err = readOtherW2(*otherW2); err != nil {
+			glog.Fatalf("--other-w2: %v", err)
+		}
 	}
-	t, err := xml.Parse(file)
-	if err != nil {
-		glog.Fatalf("Parse: unexpected: %v", err)
+
+	var ts []tx.Transaction
+	for _, name := range inputs {
+		t, err := xml.ReadFile(name, *inputFormat, xml.Options{Lenient: *lenient, Stream: *stream})
+		if err != nil {
+			glog.Fatalf("ReadFile: %v: unexpected: %v", name, err)
+		}
+		ts = append(ts, t)
+	}
+	sort.SliceStable(ts, func(i, j int) bool {
+		return time.Time(ts[i].Date).Before(time.Time(ts[j].Date))
+	})
+	if *vestFile != "" {
+		vs, err := readVests(*vestFile)
+		if err != nil {
+			glog.Fatalf("--vest-file: %v", err)
+		}
+		for _, w := range tx.MatchVests(ts, vs) {
+			fmt.Fprintf(os.Stderr, "WARNING: %v\n", w)
+		}
+	}
+	for _, w := range tx.LinkAdjustments(ts) {
+		fmt.Fprintf(os.Stderr, "WARNING: can not link adjustment: %v\n", w)
+	}
+	for _, w := range withholding.Split(ts) {
+		fmt.Fprintf(os.Stderr, "WARNING: %v\n", w)
+	}
+	for i, t := range ts {
+		if *dateOnly {
+			fmt.Printf("%s\n", out.YMD(t.Date))
+			continue
+		}
+		if i > 0 {
+			fmt.Println()
+		}
+		if err := out.Output(t, cfg, os.Stdout); err != nil {
+			glog.Fatalf("Output: unexpected: %v", err)
+		}
 	}
-	if *dateOnly {
-		fmt.Printf("%s\n", out.YMD(t.Date))
-	} else if err := out.Output(t, cfg, os.Stdout); err != nil {
-		glog.Fatalf("Output: unexpected: %v", err)
+	reportW4Changes(os.Stderr, ts)
+	reportGaps(os.Stderr, ts)
+	for _, w := range limits.Check(ts, o) {
+		fmt.Fprintf(os.Stderr, "WARNING: %v\n", w)
 	}
 }
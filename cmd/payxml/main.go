@@ -1,56 +1,154 @@
 // Package main contains an experimental XML parsing program that produces
-// an image of the decoded bounding boxes as a PNG file.
+// an image of the decoded bounding boxes as a PNG or SVG file.  Rotated pages
+// are drawn as shown, since pdf2txt applies the page rotation to the
+// coordinates.
+//
+// The SVG output also has the text of each textline drawn in its box, and
+// the text and the coordinates of the elements as tooltips, so that a layout
+// can be inspected in a browser.
+//
+// With -serve, payxml instead serves a page at -addr for exploring the layout
+// interactively, see package explore.
 //
 // Usage:
 //
-//	payxml -input=<xml_file> -output=<png_file>
+//	payxml -input=<xml_file> -output=<png_or_svg_file> \
+//	  [-input-format=pdfminer|poppler|hocr] [-format=png|svg] \
+//	  [-all-pages] [-split] [-elements=textbox,textline,rect,figure]
+//	payxml -input=<xml_file> -serve [-addr=localhost:8080]
 package main
 
 import (
 	"flag"
+	"fmt"
 	"image/color"
+	"net/http"
 	"os"
+	"path/filepath"
+	"strings"
 
 	"github.com/filmil/fintools-public/pkg/draw"
+	"github.com/filmil/fintools-public/pkg/explore"
 	"github.com/filmil/fintools-public/pkg/xml"
 	"github.com/golang/glog"
 	"github.com/llgcode/draw2d/draw2dimg"
 )
 
 var (
-	input  = flag.String("input", "", "Input filename")
-	output = flag.String("output", "output.png", "output filename")
+	input       = flag.String("input", "", "Input filename")
+	output      = flag.String("output", "output.png", "output filename")
+	inputFormat = flag.String("input-format", xml.FormatPdfminer, "Format of the input file: pdfminer (pdf2txt -t xml), poppler (pdftotext -bbox-layout) or hocr (tesseract)")
+	format      = flag.String("format", "", "Format of the output file: png or svg.  Taken from the --output file extension if not set")
+	allPages    = flag.Bool("all-pages", false, "Draw all pages, not only the first one")
+	split       = flag.Bool("split", false, "Write one file per page, named like --output with the page number added, e.g. output-1.svg")
+	elements    = flag.String("elements", "", "Comma separated element classes to draw in SVG: textbox, textline, rect, figure.  All if empty")
+	serve       = flag.Bool("serve", false, "Serve an interactive layout explorer at --addr instead of writing an image")
+	addr        = flag.String("addr", "localhost:8080", "Address to serve the layout explorer at, with --serve")
 )
 
+// pageFile returns the name of the output file for the page with the given
+// index, counting from 0.
+func pageFile(name string, i int) string {
+	ext := filepath.Ext(name)
+	return fmt.Sprintf("%s-%d%s", strings.TrimSuffix(name, ext), i+1, ext)
+}
+
+func writePNG(name string, page xml.Page) {
+	dest := draw.ImageForPage(page)
+	gc := draw2dimg.NewGraphicContext(dest)
+	gc.SetDPI(72)
+	// Set some properties
+	gc.SetFillColor(color.RGBA{0xff, 0xff, 0xff, 0xff})
+	gc.SetStrokeColor(color.RGBA{0x00, 0x00, 0x00, 0xff})
+	gc.Scale(10, -10)
+	gc.Translate(0, -page.BBox.Top)
+	gc.SetLineWidth(1)
+	draw.FillBox(gc, page.BBox)
+	page.ForAllBBox(draw.WithCtx(gc, draw.Box))
+
+	// Save to file
+	if err := draw2dimg.SaveToPngFile(name, dest); err != nil {
+		glog.Fatalf("writing %q: %v", name, err)
+	}
+}
+
+func writeSVG(name string, pages []xml.Page, o draw.SVGOptions) {
+	f, err := os.Create(name)
+	if err != nil {
+		glog.Fatalf("can not create %q: %v", name, err)
+	}
+	if err := draw.SVG(f, pages, o); err != nil {
+		glog.Fatalf("writing %q: %v", name, err)
+	}
+	if err := f.Close(); err != nil {
+		glog.Fatalf("writing %q: %v", name, err)
+	}
+}
+
 func main() {
 	flag.Parse()
 
 	if *input == "" {
 		glog.Fatalf("--input=... is mandatory")
 	}
+	f := *format
+	if f == "" {
+		f = "png"
+		if strings.EqualFold(filepath.Ext(*output), ".svg") {
+			f = "svg"
+		}
+	}
+	if f != "png" && f != "svg" {
+		glog.Fatalf("unknown --format=%q, want png or svg", f)
+	}
+	if f == "png" && *allPages && !*split && !*serve {
+		glog.Fatalf("a PNG file holds a single page, use --split with --all-pages")
+	}
+	classes, err := draw.ParseClasses(*elements)
+	if err != nil {
+		glog.Fatalf("--elements: %v", err)
+	}
 
 	file, err := os.Open(*input)
 	if err != nil {
 		glog.Fatalf("can not open %q: %v", *input, err)
 	}
 
-	paystub, err := xml.Decode(file)
+	var paystub xml.Paystub
+	if *inputFormat == xml.FormatPdfminer && !*allPages && !*serve {
+		// Only the first page is drawn, so don't bother decoding the rest.
+		paystub, err = xml.DecodeFirstPage(file, xml.StreamOptions{})
+	} else {
+		paystub, err = xml.DecodeFormat(file, *inputFormat)
+	}
 	if err != nil {
-		glog.Fatalf("xml.Parse(%q)=%v", *input, err)
+		glog.Fatalf("decoding %q: %v", *input, err)
+	}
+	pages := paystub.Pages
+	if len(pages) == 0 {
+		glog.Fatalf("no pages in %q", *input)
+	}
+	if *serve {
+		glog.Infof("serving the layout of %q at http://%v", *input, *addr)
+		glog.Fatal(http.ListenAndServe(*addr, explore.New(paystub)))
+	}
+	if !*allPages {
+		pages = pages[:1]
 	}
-	page := paystub.Pages[0]
-	dest := draw.ImageForPage(page)
-	gc := draw2dimg.NewGraphicContext(dest)
-	gc.SetDPI(72)
-	// Set some properties
-	gc.SetFillColor(color.RGBA{0xff, 0xff, 0xff, 0xff})
-	gc.SetStrokeColor(color.RGBA{0x00, 0x00, 0x00, 0xff})
-	gc.Scale(10, -10)
-	gc.Translate(0, -page.BBox.Top)
-	gc.SetLineWidth(1)
-	draw.FillBox(gc, page.BBox)
-	page.ForAllBBox(draw.WithCtx(gc, draw.Box))
 
-	// Save to file
-	draw2dimg.SaveToPngFile(*output, dest)
+	o := draw.SVGOptions{Classes: classes}
+	switch {
+	case f == "svg" && !*split:
+		writeSVG(*output, pages, o)
+	case f == "svg":
+		for i, p := range pages {
+			writeSVG(pageFile(*output, i), []xml.Page{p}, o)
+		}
+	case !*split:
+		writePNG(*output, pages[0])
+	default:
+		for i, p := range pages {
+			writePNG(pageFile(*output, i), p)
+		}
+	}
 }
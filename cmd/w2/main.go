@@ -0,0 +1,79 @@
+// Package main contains a program that parses a W-2 wage and tax statement
+// from the pdf2txt XML.
+//
+// Usage:
+//
+//	w2 -input=<xml_file> [-format=json|beancount] [-year=2020]
+//
+// The beancount output asserts the balances of the tax accounts at the end
+// of the tax year.  The default accounts are the ones that paystub uses.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/filmil/fintools-public/pkg/w2"
+	"github.com/filmil/fintools-public/pkg/xml"
+	"github.com/golang/glog"
+)
+
+func t(s string) string {
+	return fmt.Sprintf("Expenses:Personal:Taxes:Y%%s:%s", s)
+}
+
+var (
+	input  = flag.String("input", "", "pdf2txt XML file of the W-2")
+	format = flag.String("format", "json", "Output format: json or beancount")
+	year   = flag.Int("year", 0, "Tax year, if the form does not say")
+
+	accounts w2.Accounts
+)
+
+func setFlags() {
+	flag.StringVar(&accounts.FederalIncomeTax, "federal-income-tax", t("FederalIncomeTax"), "")
+	flag.StringVar(&accounts.SocialSecurityTax, "social-security-employee-tax", t("SocialSecurityEmployeeTax"), "")
+	flag.StringVar(&accounts.MedicareTax, "employee-medicare", t("EmployeeMedicare"), "")
+	flag.StringVar(&accounts.StateIncomeTax, "ca-state-income-tax", t("CaStateIncomeTax"), "")
+}
+
+func main() {
+	setFlags()
+	flag.Parse()
+
+	if *input == "" {
+		glog.Fatalf("--input=... is mandatory")
+	}
+	file, err := os.Open(*input)
+	if err != nil {
+		glog.Fatalf("can not open %q: %v", *input, err)
+	}
+	defer file.Close()
+	p, err := xml.Decode(file)
+	if err != nil {
+		glog.Fatalf("xml.Decode(%q)=%v", *input, err)
+	}
+	w, err := w2.Parse(p)
+	if err != nil {
+		glog.Fatalf("w2.Parse(%q)=%v", *input, err)
+	}
+	if w.Year == 0 {
+		w.Year = *year
+	}
+
+	switch *format {
+	case "json":
+		e := json.NewEncoder(os.Stdout)
+		e.SetIndent("", "  ")
+		err = e.Encode(w)
+	case "beancount":
+		err = w2.Beancount(os.Stdout, w, accounts)
+	default:
+		glog.Fatalf("unknown --format=%q, want json or beancount", *format)
+	}
+	if err != nil {
+		glog.Fatalf("output: %v", err)
+	}
+}
@@ -0,0 +1,332 @@
+// Package anon replaces the personal data in a decoded paystub with fake
+// values, so that the paystub can be checked in as a test fixture.
+//
+// The page geometry is kept as is.  The text of each textline is classified:
+//
+//   - amounts such as "$1,234.56", "(10.00)" or "10.00-", and rates such as
+//     "$50.00/hr", are scaled by a common factor, except for the hours, so
+//     that hours times rate is still the amount;
+//   - the vested and withheld shares and the vest symbol are kept, so that
+//     the shares times the scaled FMV are still the stock unit income;
+//   - dates such as "01/18/2019" are kept, since the parser needs them;
+//   - filing statuses are replaced by a fixed fake one;
+//   - known paystub labels and their words are kept;
+//   - everything else has its letters and digits replaced by fake ones.
+//
+// The same input word is always replaced by the same fake word, so that a name
+// that appears in several places remains consistent.
+package anon
+
+import (
+	"fmt"
+	"hash/fnv"
+	"math"
+	"math/rand"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/filmil/fintools-public/pkg/tx"
+	"github.com/filmil/fintools-public/pkg/xml"
+)
+
+// headerLabels are the labels on a paystub that are not line items, but are
+// needed for parsing it.
+var headerLabels = []string{
+	"Pay Statement", "Pay Date", "Document", "Net Pay", "Earnings", "Pay Type",
+	"Hours", "Rate", "Current", "YTD", "Total Hours Worked", "Deductions",
+	"Deduction", "Employee", "Employer", "Taxes", "Tax", "Paid Time Off",
+	"Plan", "Taken", "Balance", "Pay Period", "Period Start", "Period End",
+	"Check Date", "Employee ID", "Pay Rate", "Department", "Company", "Total",
+	"Gross Pay", "Pre Tax Deductions", "Post Tax Deductions", "Begin",
+	"Beginning", "Ending", "Annual Salary", "Number", "Tax Withholding Information",
+	"Federal", "State", "Filing Status", "Marital Status", "Allowances",
+	"Exemptions", "Additional Withholding", "Extra Withholding",
+	"Original Document", "Original Pay Date", "Reversal", "Void", "Correction",
+	"Adjustment", "Off-Cycle", "Off Cycle", "Payment",
+	"Stock Vest Details", "Vest Date", "Release Date", "Symbol", "Ticker",
+	"Shares Vested", "Units Vested", "Shares Released", "Shares Withheld",
+	"Units Withheld", "Vest FMV", "Fair Market Value", "Release Price",
+}
+
+// Options modify the anonymization.
+type Options struct {
+	// Scale multiplies all amounts and rates, but not the hours and the
+	// vested shares.  Integer scales keep all sums on the paystub exact to
+	// the cent; other scales may be off by a cent due to rounding.  Zero
+	// means 1.
+	Scale float64
+	// Seed selects the fake values.  Different seeds produce different fake
+	// values for the same input.
+	Seed int64
+	// Keep are additional words that are kept verbatim.
+	Keep []string
+}
+
+var (
+	// amountRe matches the amounts, the rates and the hours.  Its groups are
+	// the parts of the text around the integer and the fractional digits.
+	// The part after them includes the unit of a rate, as in "$50.00/hr" or
+	// "150,000.00 per year".
+	amountRe = regexp.MustCompile(`^(\$?\(?-?\$?)([0-9,]*[0-9])\.([0-9]{2,4})(\)?-?(?:/[a-z]+| per [a-z]+)?)$`)
+	dateRe   = regexp.MustCompile(`^[0-9]{2}/[0-9]{2}/[0-9]{4}$`)
+)
+
+// Labels of the hours, which are not scaled.
+const (
+	hoursColumn = "Hours"
+	hoursTotal  = "Total Hours Worked"
+)
+
+// vestLabels are the labels of the vest details whose values are kept: the
+// share counts, and the symbol, which is not personal.
+var vestLabels = []string{
+	"Symbol", "Ticker", "Shares Vested", "Units Vested", "Shares Released",
+	"Shares Withheld", "Units Withheld",
+}
+
+// statusLabels are the suffixes of the labels of the filing statuses, which
+// are replaced by fakeStatus.
+var statusLabels = []string{"Filing Status", "Marital Status"}
+
+const fakeStatus = "Single"
+
+// rightOf returns the textlines on the same line as h and right of it, from
+// left to right.  The textlines that only touch the line are not on it.
+func rightOf(tls []xml.Textline, h xml.Textline) []xml.Textline {
+	mid := (h.BBox.Bottom + h.BBox.Top) / 2
+	return xml.SortLeft(xml.MatchPredicate(tls, func(tl xml.Textline) bool {
+		return tl.BBox.Left > h.BBox.Right && tl.BBox.Bottom < mid && mid < tl.BBox.Top
+	}))
+}
+
+type anonymizer struct {
+	o    Options
+	keep map[string]bool
+}
+
+// Anonymize returns a copy of p with the personal data replaced.
+func Anonymize(p xml.Paystub, o Options) xml.Paystub {
+	if o.Scale == 0 {
+		o.Scale = 1
+	}
+	a := anonymizer{o: o, keep: map[string]bool{}}
+	labels := append([]string{}, headerLabels...)
+	for _, s := range tx.Sections {
+		labels = append(labels, tx.Labels(s)...)
+	}
+	labels = append(labels, o.Keep...)
+	for _, l := range labels {
+		for _, w := range strings.Fields(l) {
+			a.keep[w] = true
+		}
+	}
+	r := xml.Paystub{XMLName: p.XMLName}
+	for _, pg := range p.Pages {
+		r.Pages = append(r.Pages, a.page(pg))
+	}
+	return r
+}
+
+// hours returns the bounding boxes of the hours on the page: the amounts
+// in the column under an "Hours" header, up to the first text that is not an
+// amount, and the amount right of "Total Hours Worked".
+func hours(p xml.Page) map[xml.BBox]bool {
+	tls := xml.Textlines(p)
+	r := map[xml.BBox]bool{}
+	for _, h := range tls {
+		switch h.Text() {
+		case hoursColumn:
+			for _, tl := range xml.SortTop(xml.FindInBBox(tls, h.BBox.ExtendBottom().Below(h.BBox))) {
+				if !amountRe.MatchString(tl.Text()) {
+					break
+				}
+				r[tl.BBox] = true
+			}
+		case hoursTotal:
+			if ts := rightOf(tls, h); len(ts) > 0 && amountRe.MatchString(ts[0].Text()) {
+				r[ts[0].BBox] = true
+			}
+		}
+	}
+	return r
+}
+
+// vests returns the bounding boxes of the kept vest details on the page: the
+// first textline right of each of the vestLabels.
+func vests(p xml.Page) map[xml.BBox]bool {
+	tls := xml.Textlines(p)
+	r := map[xml.BBox]bool{}
+	for _, l := range vestLabels {
+		for _, h := range xml.MatchPredicate(tls, xml.BindText(l, xml.MatchingText)) {
+			if ts := rightOf(tls, h); len(ts) > 0 {
+				r[ts[0].BBox] = true
+			}
+		}
+	}
+	return r
+}
+
+// statuses returns the bounding boxes of the filing statuses on the page:
+// all the textlines right of a filing status label, which are one per column
+// in a table of the withholding settings.
+func statuses(p xml.Page) map[xml.BBox]bool {
+	tls := xml.Textlines(p)
+	r := map[xml.BBox]bool{}
+	for _, l := range statusLabels {
+		for _, h := range xml.MatchPredicate(tls, xml.BindText(l, xml.MatchingSuffix)) {
+			for _, tl := range rightOf(tls, h) {
+				r[tl.BBox] = true
+			}
+		}
+	}
+	return r
+}
+
+func (a anonymizer) page(p xml.Page) xml.Page {
+	hs, vs, ss := hours(p), vests(p), statuses(p)
+	tbs := make([]xml.Textbox, len(p.Textboxes))
+	for i, tb := range p.Textboxes {
+		tls := make([]xml.Textline, len(tb.Textlines))
+		for j, tl := range tb.Textlines {
+			switch {
+			case hs[tl.BBox], vs[tl.BBox]:
+				tls[j] = tl
+			case ss[tl.BBox]:
+				tl.Texts = retext(tl, fakeStatus)
+				tls[j] = tl
+			default:
+				tls[j] = a.textline(tl)
+			}
+		}
+		tb.Textlines = tls
+		tbs[i] = tb
+	}
+	p.Textboxes = tbs
+	return p
+}
+
+// text returns the anonymized version of the text s.
+func (a anonymizer) text(s string) string {
+	switch {
+	case amountRe.MatchString(s):
+		return a.amount(s)
+	case dateRe.MatchString(s):
+		return s
+	}
+	w := strings.Split(s, " ")
+	for i := range w {
+		if !a.keep[w[i]] {
+			w[i] = a.word(w[i])
+		}
+	}
+	return strings.Join(w, " ")
+}
+
+// amount scales the amount s, keeping its formatting: the sign, the dollar
+// sign, the thousands separators and the number of decimals.
+func (a anonymizer) amount(s string) string {
+	m := amountRe.FindStringSubmatch(s)
+	if m == nil {
+		// The caller checks for an amount.
+		panic(fmt.Sprintf("not an amount: %q", s))
+	}
+	before, digits, decimals, after := m[1], m[2], m[3], m[4]
+	v, err := strconv.ParseFloat(strings.ReplaceAll(digits, ",", "")+"."+decimals, 64)
+	if err != nil {
+		// The regexp guarantees a number.
+		panic(fmt.Sprintf("not an amount: %q: %v", s, err))
+	}
+	// Amounts under 1000 give no hint on whether to use separators.
+	commas := strings.Contains(digits, ",") || v < 1000
+	unit := math.Pow10(len(decimals))
+	units := int64(math.Round(math.Round(v*unit) * a.o.Scale))
+	n := fmt.Sprintf("%d", units/int64(unit))
+	if commas {
+		n = thousands(n)
+	}
+	return fmt.Sprintf("%s%s.%0*d%s", before, n, len(decimals), units%int64(unit), after)
+}
+
+// thousands inserts thousands separators into the digit string n.
+func thousands(n string) string {
+	var b strings.Builder
+	for i, c := range n {
+		if i > 0 && (len(n)-i)%3 == 0 {
+			b.WriteRune(',')
+		}
+		b.WriteRune(c)
+	}
+	return b.String()
+}
+
+// word returns a fake word for w, which has the same shape as w: letters are
+// replaced by letters of the same case, digits by digits, and everything else
+// is kept.
+func (a anonymizer) word(w string) string {
+	h := fnv.New64a()
+	fmt.Fprintf(h, "%d:%s", a.o.Seed, w)
+	r := rand.New(rand.NewSource(int64(h.Sum64())))
+	return strings.Map(func(c rune) rune {
+		switch {
+		case unicode.IsUpper(c):
+			return rune('A' + r.Intn(26))
+		case unicode.IsLower(c):
+			return rune('a' + r.Intn(26))
+		case unicode.IsDigit(c):
+			return rune('0' + r.Intn(10))
+		}
+		return c
+	}, w)
+}
+
+func (a anonymizer) textline(tl xml.Textline) xml.Textline {
+	old := tl.Text()
+	s := a.text(old)
+	if s == old {
+		return tl
+	}
+	tl.Texts = retext(tl, s)
+	return tl
+}
+
+// retext returns the Text elements for textline tl, changed to contain s.
+// If s is as long as the original text, the per-character bounding boxes are
+// kept.  Otherwise, the characters of s are spread evenly across the
+// textline.
+func retext(tl xml.Textline, s string) []xml.Text {
+	var chars []xml.Text
+	var font string
+	var size float64
+	for _, t := range tl.Texts {
+		if t.T == "\n" {
+			continue
+		}
+		chars = append(chars, t)
+		if font == "" && t.Font != "" {
+			font, size = t.Font, t.Size
+		}
+	}
+	rs := []rune(s)
+	var r []xml.Text
+	if len(rs) == len(chars) {
+		for i, c := range chars {
+			c.T = string(rs[i])
+			r = append(r, c)
+		}
+	} else {
+		w := (tl.BBox.Right - tl.BBox.Left) / float64(len(rs))
+		for i, c := range rs {
+			t := xml.Text{T: string(c)}
+			if c != ' ' {
+				t.Font, t.Size = font, size
+				t.BBox = tl.BBox
+				t.BBox.Left = tl.BBox.Left + float64(i)*w
+				t.BBox.Right = t.BBox.Left + w
+			}
+			r = append(r, t)
+		}
+	}
+	return append(r, xml.Text{T: "\n"})
+}
@@ -0,0 +1,214 @@
+package anon
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/filmil/fintools-public/pkg/gen"
+	"github.com/filmil/fintools-public/pkg/tx"
+	"github.com/filmil/fintools-public/pkg/xml"
+	"github.com/google/go-cmp/cmp"
+)
+
+func textline(left, bottom float64, s string) xml.Textline {
+	tl := xml.Textline{BBox: xml.BBox{Left: left, Bottom: bottom, Top: bottom + 10}}
+	for _, c := range s {
+		t := xml.Text{T: string(c)}
+		if c != ' ' {
+			t.Font = "ArialMT"
+			t.Size = 10
+			t.BBox = xml.BBox{Left: left, Right: left + 5, Bottom: bottom, Top: bottom + 10}
+		}
+		tl.Texts = append(tl.Texts, t)
+		left += 5
+	}
+	tl.BBox.Right = left
+	tl.Texts = append(tl.Texts, xml.Text{T: "\n"})
+	return tl
+}
+
+func paystub(tls ...xml.Textline) xml.Paystub {
+	return xml.Paystub{
+		Pages: []xml.Page{{
+			ID:   "1",
+			BBox: xml.BBox{Right: 612, Top: 792},
+			Textboxes: []xml.Textbox{{
+				BBox:      xml.BBox{Right: 612, Top: 792},
+				Textlines: tls,
+			}},
+		}},
+	}
+}
+
+func TestAnonymize(t *testing.T) {
+	t.Parallel()
+	in := paystub(
+		textline(10, 700, "Jane Doe"),
+		textline(10, 690, "Pay Date"),
+		textline(60, 690, "01/18/2019"),
+		textline(10, 680, "Document"),
+		textline(60, 680, "13541270"),
+		textline(10, 670, "Regular Pay"),
+		textline(60, 670, "$1,234.56"),
+		textline(10, 660, "Jane Doe"),
+		textline(60, 660, "(10.01)"),
+		textline(90, 660, "999.99"),
+	)
+	out := Anonymize(in, Options{Scale: 2, Seed: 1})
+
+	texts := xml.TextOf(xml.Textlines(out.Pages[0]))
+	if texts[0] == "Jane Doe" {
+		t.Errorf("name was not replaced: %q", texts[0])
+	}
+	if texts[0] != texts[7] {
+		t.Errorf("same name replaced inconsistently: %q vs %q", texts[0], texts[7])
+	}
+	if texts[4] == "13541270" || len(texts[4]) != 8 {
+		t.Errorf("document number not replaced by a same-length number: %q", texts[4])
+	}
+	expected := map[int]string{
+		1: "Pay Date",
+		2: "01/18/2019",
+		3: "Document",
+		5: "Regular Pay",
+		6: "$2,469.12",
+		8: "(20.02)",
+		9: "1,999.98",
+	}
+	for i, e := range expected {
+		if texts[i] != e {
+			t.Errorf("texts[%d]=%q, want: %q", i, texts[i], e)
+		}
+	}
+
+	// Geometry is unchanged.
+	for i, tl := range xml.Textlines(out.Pages[0]) {
+		if tl.BBox != in.Pages[0].Textboxes[0].Textlines[i].BBox {
+			t.Errorf("textline %d moved: %v", i, tl.BBox)
+		}
+	}
+
+	// The result encodes to XML that decodes back to the same thing.
+	var b bytes.Buffer
+	if err := xml.Encode(&b, out); err != nil {
+		t.Fatalf("Encode: unexpected error: %v", err)
+	}
+	back, err := xml.Decode(strings.NewReader(b.String()))
+	if err != nil {
+		t.Fatalf("Decode: unexpected error: %v\n%v", err, b.String())
+	}
+	if diff := cmp.Diff(texts, xml.TextOf(xml.Textlines(back.Pages[0]))); diff != "" {
+		t.Errorf("round trip diff:\n%v", diff)
+	}
+}
+
+func TestAnonymizeSeed(t *testing.T) {
+	t.Parallel()
+	in := paystub(textline(10, 700, "Jane Doe"))
+	a := xml.TextOf(xml.Textlines(Anonymize(in, Options{Seed: 1}).Pages[0]))
+	b := xml.TextOf(xml.Textlines(Anonymize(in, Options{Seed: 2}).Pages[0]))
+	if a[0] == b[0] {
+		t.Errorf("different seeds gave the same fake value: %q", a[0])
+	}
+}
+
+func TestAnonymizeAmounts(t *testing.T) {
+	t.Parallel()
+	in := paystub(
+		textline(10, 700, "$(10.00)"),
+		textline(10, 690, "10.00-"),
+		textline(10, 680, "-$1,000.00"),
+		textline(10, 670, "Pay Type"),
+		textline(60, 670, "Hours"),
+		textline(90, 670, "Rate"),
+		textline(10, 660, "Regular Pay"),
+		textline(60, 660, "80.00"),
+		textline(90, 660, "62.5000"),
+		textline(120, 660, "5,000.00"),
+		textline(10, 650, "Total Hours Worked"),
+		textline(110, 650, "80.00"),
+		textline(10, 640, "Taxes"),
+		textline(60, 630, "12.34"),
+		textline(10, 620, "$52.50/hr"),
+		textline(10, 610, "150,000.00 per year"),
+	)
+	actual := xml.TextOf(xml.Textlines(Anonymize(in, Options{Scale: 2}).Pages[0]))
+	expected := []string{
+		"$(20.00)",
+		"20.00-",
+		"-$2,000.00",
+		"Pay Type",
+		"Hours",
+		"Rate",
+		"Regular Pay",
+		// The hours are not scaled, the rate and the amount are.
+		"80.00",
+		"125.0000",
+		"10,000.00",
+		"Total Hours Worked",
+		"80.00",
+		"Taxes",
+		// Under the hours column, but past the end of it.
+		"24.68",
+		"$105.00/hr",
+		"300,000.00 per year",
+	}
+	if diff := cmp.Diff(expected, actual); diff != "" {
+		t.Errorf("Anonymize(_)=%v, want: %v\ndiff:\n%v", actual, expected, diff)
+	}
+}
+
+func TestAnonymizeFilingStatus(t *testing.T) {
+	t.Parallel()
+	in := paystub(
+		textline(150, 110, "Federal"),
+		textline(250, 110, "State"),
+		textline(10, 100, "Marital Status"),
+		textline(150, 100, "Married"),
+		textline(250, 100, "Head of Household"),
+		textline(10, 80, "State Filing Status"),
+		textline(150, 80, "Married Filing Separately"),
+		textline(10, 60, "Married"),
+	)
+	actual := xml.TextOf(xml.Textlines(Anonymize(in, Options{Seed: 1}).Pages[0]))
+	expected := []string{"Federal", "State", "Marital Status", "Single", "Single", "State Filing Status", "Single"}
+	if diff := cmp.Diff(expected, actual[:len(expected)]); diff != "" {
+		t.Errorf("Anonymize(_)=%v, want: %v\ndiff:\n%v", actual, expected, diff)
+	}
+	if actual[len(expected)] == "Married" {
+		t.Errorf("status word outside of the withholding settings was kept: %q", actual[len(expected)])
+	}
+}
+
+func TestAnonymizeVest(t *testing.T) {
+	t.Parallel()
+	date := tx.DateOnly(time.Date(2020, 1, 31, 0, 0, 0, 0, time.UTC))
+	in := tx.Transaction{
+		Date:             date,
+		DocNum:           "13541270",
+		GoogStockUnit:    14505,
+		FederalIncomeTax: 5802,
+		GSUCRefund:       8703,
+		Vests: []tx.Vest{{
+			Date:   tx.DateOnly(time.Date(2020, 1, 25, 0, 0, 0, 0, time.UTC)),
+			Symbol: "GOOG", Shares: 10, WithheldShares: 4, FMV: 1450.5,
+		}},
+	}
+	const scale = 3
+	actual, err := xml.Convert(Anonymize(gen.Google(in, gen.Options{}), Options{Scale: scale, Seed: 1}))
+	if err != nil {
+		t.Fatalf("Convert: unexpected error: %v", err)
+	}
+	expected := []tx.Vest{{
+		Date:   in.Vests[0].Date,
+		Symbol: "GOOG", Shares: 10, WithheldShares: 4, FMV: 1450.5 * scale,
+	}}
+	if diff := cmp.Diff(expected, actual.Vests, cmp.Comparer(tx.DateOnly.Equal)); diff != "" {
+		t.Errorf("Anonymize(_).Vests=%+v, want: %+v\ndiff:\n%v", actual.Vests, expected, diff)
+	}
+	if v, e := actual.Vests[0].Value(), actual.GoogStockUnit; v != e || e != in.GoogStockUnit*scale {
+		t.Errorf("vest value %v, want the stock unit income %v, %v times %v", v, e, scale, in.GoogStockUnit)
+	}
+}
@@ -0,0 +1,146 @@
+// Package diff compares two paystubs line item by line item, to explain why
+// the net pay changed between two pay periods.
+package diff
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/filmil/fintools-public/pkg/tx"
+)
+
+// Statuses of the items.
+const (
+	StatusSame    = "same"
+	StatusChanged = "changed"
+	// StatusAdded is an item that is only on the new paystub.
+	StatusAdded = "added"
+	// StatusRemoved is an item that is only on the old paystub.
+	StatusRemoved = "removed"
+)
+
+// sectionTotals is the section of the totals that are not line items.
+const sectionTotals = "Totals"
+
+// Item is a line item of either paystub.
+type Item struct {
+	Section string
+	Label   string
+	Old     tx.USD
+	New     tx.USD
+	// Change is New - Old.
+	Change tx.USD
+	// Percent is Change relative to the magnitude of Old, or nil if Old is
+	// zero.
+	Percent *float64 `json:",omitempty"`
+	// Status is one of the Status* constants.
+	Status string
+}
+
+// Paystub identifies a paystub in the comparison.
+type Paystub struct {
+	Date   tx.DateOnly
+	DocNum string `json:",omitempty"`
+}
+
+// Result is the comparison of two paystubs.
+type Result struct {
+	Old Paystub
+	New Paystub
+	// Header are the changes of the header fields and the withholding
+	// settings, e.g. `pay rate: 100.0000 USD -> 110.0000 USD`.
+	Header []string `json:",omitempty"`
+	// Items are the line items of both paystubs, ordered by section and
+	// then by label, followed by the gross and the net pay.
+	Items []Item
+}
+
+// header returns the changes of the header fields between a and b.  The
+// fields that are on one of the paystubs only are not compared, since most
+// paystubs do not have them all.
+func header(a, b tx.Transaction) []string {
+	var r []string
+	add := func(name string, p, n interface{}, known bool) {
+		if known && p != n {
+			r = append(r, fmt.Sprintf("%v: %v -> %v", name, p, n))
+		}
+	}
+	add("kind", fmt.Sprintf("%q", a.Kind), fmt.Sprintf("%q", b.Kind), true)
+	// Consecutive pay periods always differ in their dates, so only their
+	// lengths are compared.
+	days := func(t tx.Transaction) int {
+		return int(math.Round(time.Time(t.PayPeriodEnd).Sub(time.Time(t.PayPeriodStart)).Hours()/24)) + 1
+	}
+	known := func(t tx.Transaction) bool {
+		return !t.PayPeriodStart.IsZero() && !t.PayPeriodEnd.IsZero()
+	}
+	add("pay period days", days(a), days(b), known(a) && known(b))
+	add("pay rate", a.PayRate, b.PayRate, a.PayRate != 0 && b.PayRate != 0)
+	add("employee id", fmt.Sprintf("%q", a.EmployeeID), fmt.Sprintf("%q", b.EmployeeID), a.EmployeeID != "" && b.EmployeeID != "")
+	add("department", fmt.Sprintf("%q", a.Department), fmt.Sprintf("%q", b.Department), a.Department != "" && b.Department != "")
+	add("company", fmt.Sprintf("%q", a.Company), fmt.Sprintf("%q", b.Company), a.Company != "" && b.Company != "")
+	if !a.W4.IsZero() && !b.W4.IsZero() {
+		r = append(r, b.W4.Changes(a.W4)...)
+	}
+	return r
+}
+
+// item returns the item with the old and the new amount.
+func item(section, label string, o, n tx.USD) Item {
+	i := Item{Section: section, Label: label, Old: o, New: n, Change: tx.Round(n - o)}
+	switch {
+	case tx.Round(o) == 0 && tx.Round(n) != 0:
+		i.Status = StatusAdded
+	case tx.Round(o) != 0 && tx.Round(n) == 0:
+		i.Status = StatusRemoved
+	case i.Change != 0:
+		i.Status = StatusChanged
+	default:
+		i.Status = StatusSame
+	}
+	if tx.Round(o) != 0 {
+		p := float64(i.Change) / math.Abs(float64(o))
+		i.Percent = &p
+	}
+	return i
+}
+
+// Compare compares the old paystub a with the new paystub b.
+func Compare(a, b tx.Transaction) Result {
+	r := Result{
+		Old:    Paystub{Date: a.Date, DocNum: a.DocNum},
+		New:    Paystub{Date: b.Date, DocNum: b.DocNum},
+		Header: header(a, b),
+	}
+	type key struct{ section, label string }
+	var keys []key
+	amounts := map[key][2]tx.USD{}
+	for n, t := range []tx.Transaction{a, b} {
+		for _, i := range t.Items() {
+			k := key{i.Section, i.Label}
+			v, ok := amounts[k]
+			if !ok {
+				keys = append(keys, k)
+			}
+			v[n] += i.Amount
+			amounts[k] = v
+		}
+	}
+	sort.SliceStable(keys, func(i, j int) bool {
+		a, b := keys[i], keys[j]
+		if a.section != b.section {
+			return tx.SectionIndex(a.section) < tx.SectionIndex(b.section)
+		}
+		return a.label < b.label
+	})
+	for _, k := range keys {
+		v := amounts[k]
+		r.Items = append(r.Items, item(k.section, k.label, v[0], v[1]))
+	}
+	r.Items = append(r.Items,
+		item(sectionTotals, "Gross Pay", a.Gross(), b.Gross()),
+		item(sectionTotals, "Net Pay", a.NetPay, b.NetPay))
+	return r
+}
@@ -0,0 +1,92 @@
+package diff
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/filmil/fintools-public/pkg/tx"
+	"github.com/google/go-cmp/cmp"
+)
+
+func date(year int, month time.Month, day int) tx.DateOnly {
+	return tx.DateOnly(time.Date(year, month, day, 0, 0, 0, 0, time.UTC))
+}
+
+func paystubs() (tx.Transaction, tx.Transaction) {
+	a := tx.Transaction{
+		Date:             date(2020, 1, 15),
+		DocNum:           "00001",
+		PayRate:          100,
+		RegularPay:       4000,
+		Medical:          100,
+		FederalIncomeTax: 800,
+		NetPay:           3100,
+		W4:               tx.W4{FederalFilingStatus: "Single"},
+	}
+	b := a
+	b.Date = date(2020, 1, 31)
+	b.DocNum = "00002"
+	b.PayRate = 110
+	b.RegularPay = 4400
+	b.Medical = 0
+	b.Vision = 10
+	b.FederalIncomeTax = 880
+	b.NetPay = 3510
+	b.W4 = tx.W4{FederalFilingStatus: "Married"}
+	return a, b
+}
+
+func percent(p float64) *float64 {
+	return &p
+}
+
+func TestCompare(t *testing.T) {
+	t.Parallel()
+	a, b := paystubs()
+	expected := Result{
+		Old: Paystub{Date: a.Date, DocNum: "00001"},
+		New: Paystub{Date: b.Date, DocNum: "00002"},
+		Header: []string{
+			"pay rate: 100.0000 USD -> 110.0000 USD",
+			`federal filing status: "Single" -> "Married"`,
+		},
+		Items: []Item{
+			{tx.SectionEarnings, "Regular Pay", 4000, 4400, 400, percent(0.1), StatusChanged},
+			{tx.SectionDeductions, "Medical", 100, 0, -100, percent(-1), StatusRemoved},
+			{tx.SectionDeductions, "Vision", 0, 10, 10, nil, StatusAdded},
+			{tx.SectionTaxes, "Federal Income Tax", 800, 880, 80, percent(0.1), StatusChanged},
+			{sectionTotals, "Gross Pay", 4000, 4400, 400, percent(0.1), StatusChanged},
+			{sectionTotals, "Net Pay", 3100, 3510, 410, percent(410.0 / 3100), StatusChanged},
+		},
+	}
+	actual := Compare(a, b)
+	if diff := cmp.Diff(expected, actual, cmp.Comparer(tx.DateOnly.Equal)); diff != "" {
+		t.Errorf("Compare(%+v, %+v)=%+v, want: %+v\ndiff:\n%v", a, b, actual, expected, diff)
+	}
+}
+
+func TestWriteText(t *testing.T) {
+	t.Parallel()
+	a, b := paystubs()
+	b.Vision = 0
+	b.NetPay = 3520
+	expected := `2020-01-15 (00001) -> 2020-01-31 (00002)
+  pay rate: 100.0000 USD -> 110.0000 USD
+  federal filing status: "Single" -> "Married"
+
+Section     Item                Old      New      Change   %        
+Earnings    Regular Pay         4000.00  4400.00  +400.00  +10.00%  
+Deductions  Medical             100.00   0.00     -100.00  removed  
+Taxes       Federal Income Tax  800.00   880.00   +80.00   +10.00%  
+Totals      Gross Pay           4000.00  4400.00  +400.00  +10.00%  
+Totals      Net Pay             3100.00  3520.00  +420.00  +13.55%  
+`
+	var w bytes.Buffer
+	if err := Write(&w, Compare(a, b), FormatText); err != nil {
+		t.Fatalf("Write: unexpected error: %v", err)
+	}
+	if diff := cmp.Diff(expected, w.String()); diff != "" {
+		t.Errorf("Write(_)=%v, want: %v\ndiff:\n%v", w.String(), expected, diff)
+	}
+}
@@ -0,0 +1,62 @@
+package diff
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+)
+
+// Output formats.
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+func (p Paystub) String() string {
+	d := time.Time(p.Date).Format("2006-01-02")
+	if p.DocNum == "" {
+		return d
+	}
+	return fmt.Sprintf("%v (%v)", d, p.DocNum)
+}
+
+// text writes the header changes, and a table with a row per item.  The
+// items that are the same on both paystubs are left out.
+func text(w io.Writer, r Result) error {
+	fmt.Fprintf(w, "%v -> %v\n", r.Old, r.New)
+	for _, h := range r.Header {
+		fmt.Fprintf(w, "  %v\n", h)
+	}
+	fmt.Fprintln(w)
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "Section\tItem\tOld\tNew\tChange\t%%\t\n")
+	for _, i := range r.Items {
+		if i.Status == StatusSame && i.Section != sectionTotals {
+			continue
+		}
+		var p string
+		switch {
+		case i.Status == StatusAdded || i.Status == StatusRemoved:
+			p = i.Status
+		case i.Percent != nil:
+			p = fmt.Sprintf("%+.2f%%", *i.Percent*100)
+		}
+		fmt.Fprintf(tw, "%v\t%v\t%.2f\t%.2f\t%+.2f\t%v\t\n", i.Section, i.Label, i.Old, i.New, i.Change, p)
+	}
+	return tw.Flush()
+}
+
+// Write writes the result in the format, one of the Format* constants.
+func Write(w io.Writer, r Result, format string) error {
+	switch format {
+	case FormatText:
+		return text(w, r)
+	case FormatJSON:
+		e := json.NewEncoder(w)
+		e.SetIndent("", "  ")
+		return e.Encode(r)
+	}
+	return fmt.Errorf("unknown format %q, want %v or %v", format, FormatText, FormatJSON)
+}
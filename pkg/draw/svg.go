@@ -0,0 +1,170 @@
+package draw
+
+import (
+	"bufio"
+	"fmt"
+	"html"
+	"io"
+	"math"
+	"strings"
+
+	"github.com/filmil/fintools-public/pkg/xml"
+)
+
+// Element classes that SVG can draw.  Each drawn element has its class as the
+// SVG class attribute, so that it can be styled or hidden in a browser.
+const (
+	ClassTextbox  = "textbox"
+	ClassTextline = "textline"
+	ClassRect     = "rect"
+	ClassFigure   = "figure"
+)
+
+// AllClasses are all element classes that SVG can draw.
+var AllClasses = []string{ClassTextbox, ClassTextline, ClassRect, ClassFigure}
+
+// pageGap is the vertical space between pages drawn into the same SVG, in
+// points.
+const pageGap = 20
+
+const svgStyle = `
+ .page { fill: white; stroke: black; }
+ .textbox rect { fill: none; stroke: #3070f0; stroke-dasharray: 2 1; }
+ .textline rect { fill: none; stroke: #d03030; stroke-width: 0.3; }
+ .textline text { font-family: sans-serif; fill: #202020; }
+ .textline:hover rect { fill: #ffe08080; }
+ .rect { fill: none; stroke: #20a020; stroke-width: 0.5; }
+ .figure { fill: none; stroke: #a020a0; stroke-width: 0.5; }
+`
+
+// SVGOptions modify the SVG output.
+type SVGOptions struct {
+	// Classes are the element classes to draw.  All classes are drawn if
+	// empty.
+	Classes []string
+}
+
+func (o SVGOptions) has(class string) bool {
+	if len(o.Classes) == 0 {
+		return true
+	}
+	for _, c := range o.Classes {
+		if c == class {
+			return true
+		}
+	}
+	return false
+}
+
+// SVG writes the pages into w as a single SVG image, one page below the other.
+// One point on the page is one unit in the image.  Each textline is drawn with
+// its text in its box, and has its text and bounding box as a tooltip.
+func SVG(w io.Writer, pages []xml.Page, o SVGOptions) error {
+	var width, height float64
+	for i, p := range pages {
+		width = math.Max(width, p.BBox.Right-p.BBox.Left)
+		if i > 0 {
+			height += pageGap
+		}
+		height += p.BBox.Top - p.BBox.Bottom
+	}
+	b := bufio.NewWriter(w)
+	fmt.Fprintf(b, `<svg xmlns="http://www.w3.org/2000/svg" width="%.3f" height="%.3f" viewBox="0 0 %.3f %.3f">`+"\n",
+		width, height, width, height)
+	fmt.Fprintf(b, "<style>%s</style>\n", svgStyle)
+	var y float64
+	for i, p := range pages {
+		s := svgPage{w: b, o: o, p: p, dy: y}
+		s.draw(i)
+		y += p.BBox.Top - p.BBox.Bottom + pageGap
+	}
+	fmt.Fprintf(b, "</svg>\n")
+	return b.Flush()
+}
+
+// svgPage draws a single page, shifted down by dy.
+type svgPage struct {
+	w  io.Writer
+	o  SVGOptions
+	p  xml.Page
+	dy float64
+}
+
+// rect returns the SVG attributes for the position of the bounding box b.
+// SVG has the origin in the top left corner.
+func (s svgPage) rect(b xml.BBox) string {
+	return fmt.Sprintf(`x="%.3f" y="%.3f" width="%.3f" height="%.3f"`,
+		b.Left-s.p.BBox.Left, s.dy+s.p.BBox.Top-b.Top, b.Right-b.Left, b.Top-b.Bottom)
+}
+
+// Coords returns the bounding box coordinates as pdf2txt writes them.
+func Coords(b xml.BBox) string {
+	return fmt.Sprintf("%.3f,%.3f,%.3f,%.3f", b.Left, b.Bottom, b.Right, b.Top)
+}
+
+func (s svgPage) draw(i int) {
+	fmt.Fprintf(s.w, `<g class="page-%d" data-page="%s">`+"\n", i+1, html.EscapeString(s.p.ID))
+	fmt.Fprintf(s.w, `<rect class="page" %s/>`+"\n", s.rect(s.p.BBox))
+	if s.o.has(ClassRect) {
+		for _, r := range s.p.Rects {
+			fmt.Fprintf(s.w, `<rect class="%s" %s data-bbox="%s"/>`+"\n", ClassRect, s.rect(r.BBox), Coords(r.BBox))
+		}
+	}
+	if s.o.has(ClassFigure) {
+		for _, f := range s.p.Figures {
+			fmt.Fprintf(s.w, `<rect class="%s" %s data-bbox="%s"><title>%s %s</title></rect>`+"\n",
+				ClassFigure, s.rect(f.BBox), Coords(f.BBox), html.EscapeString(f.Name), Coords(f.BBox))
+		}
+	}
+	for _, tb := range s.p.Textboxes {
+		if s.o.has(ClassTextbox) {
+			fmt.Fprintf(s.w, `<g class="%s" data-bbox="%s"><rect %s/></g>`+"\n",
+				ClassTextbox, Coords(tb.BBox), s.rect(tb.BBox))
+		}
+		if !s.o.has(ClassTextline) {
+			continue
+		}
+		for _, tl := range tb.Textlines {
+			s.textline(tl)
+		}
+	}
+	fmt.Fprintf(s.w, "</g>\n")
+}
+
+func (s svgPage) textline(tl xml.Textline) {
+	t := html.EscapeString(tl.Text())
+	b := tl.BBox
+	h := b.Top - b.Bottom
+	size := tl.Size()
+	if size == 0 || size > h {
+		size = h
+	}
+	weight := "normal"
+	if tl.Bold() {
+		weight = "bold"
+	}
+	fmt.Fprintf(s.w, `<g class="%s" data-bbox="%s" data-text="%s">`, ClassTextline, Coords(b), t)
+	fmt.Fprintf(s.w, `<title>%s [%s]</title>`, t, Coords(b))
+	fmt.Fprintf(s.w, `<rect %s/>`, s.rect(b))
+	// The text is squeezed into the width of its box, since the font in the
+	// browser is not the one in the PDF.
+	fmt.Fprintf(s.w, `<text x="%.3f" y="%.3f" font-size="%.3f" font-weight="%s" textLength="%.3f" lengthAdjust="spacingAndGlyphs">%s</text>`,
+		b.Left-s.p.BBox.Left, s.dy+s.p.BBox.Top-b.Bottom-0.2*h, 0.8*size, weight, b.Right-b.Left, t)
+	fmt.Fprintf(s.w, "</g>\n")
+}
+
+// ParseClasses parses a comma separated list of element classes.
+func ParseClasses(s string) ([]string, error) {
+	if s == "" {
+		return nil, nil
+	}
+	var r []string
+	for _, c := range strings.Split(s, ",") {
+		c = strings.TrimSpace(c)
+		if !(SVGOptions{Classes: AllClasses}).has(c) {
+			return nil, fmt.Errorf("unknown element class %q, want one of %v", c, AllClasses)
+		}
+		r = append(r, c)
+	}
+	return r, nil
+}
@@ -0,0 +1,85 @@
+package draw
+
+import (
+	"bytes"
+	goxml "encoding/xml"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/filmil/fintools-public/pkg/xml"
+)
+
+func page(id, text string) xml.Page {
+	b := xml.BBox{Left: 40, Bottom: 700, Right: 80, Top: 710}
+	return xml.Page{
+		ID:   id,
+		BBox: xml.BBox{Left: 0, Bottom: 0, Right: 612, Top: 792},
+		Textboxes: []xml.Textbox{{
+			BBox: b,
+			Textlines: []xml.Textline{{
+				BBox:  b,
+				Texts: []xml.Text{{BBox: b, Font: "Arial-BoldMT", Size: 8, T: text}, {T: "\n"}},
+			}},
+		}},
+		Rects: []xml.Rect{{BBox: xml.BBox{Left: 10, Bottom: 10, Right: 600, Top: 11}}},
+	}
+}
+
+func TestSVG(t *testing.T) {
+	t.Parallel()
+	var b bytes.Buffer
+	pages := []xml.Page{page("1", "Earnings"), page("2", "Taxes & <more>")}
+	if err := SVG(&b, pages, SVGOptions{}); err != nil {
+		t.Fatalf("SVG: unexpected error: %v", err)
+	}
+	s := b.String()
+	// The output must be well formed, so that browsers show it.
+	d := goxml.NewDecoder(strings.NewReader(s))
+	for {
+		_, err := d.Token()
+		if err != nil {
+			if err != io.EOF {
+				t.Fatalf("SVG is not well formed: %v\n%v", err, s)
+			}
+			break
+		}
+	}
+	for _, want := range []string{
+		`height="1604.000"`,
+		`<title>Earnings [40.000,700.000,80.000,710.000]</title>`,
+		`<title>Taxes &amp; &lt;more&gt; [40.000,700.000,80.000,710.000]</title>`,
+		// The second page is drawn below the first one.
+		`<text x="40.000" y="902.000"`,
+		`font-weight="bold"`,
+		`class="rect"`,
+	} {
+		if !strings.Contains(s, want) {
+			t.Errorf("SVG(_) does not contain %q:\n%v", want, s)
+		}
+	}
+}
+
+func TestSVGClasses(t *testing.T) {
+	t.Parallel()
+	classes, err := ParseClasses("textline")
+	if err != nil {
+		t.Fatalf("ParseClasses: unexpected error: %v", err)
+	}
+	var b bytes.Buffer
+	if err := SVG(&b, []xml.Page{page("1", "Earnings")}, SVGOptions{Classes: classes}); err != nil {
+		t.Fatalf("SVG: unexpected error: %v", err)
+	}
+	s := b.String()
+	if !strings.Contains(s, `class="textline"`) {
+		t.Errorf("SVG(_) has no textline:\n%v", s)
+	}
+	for _, c := range []string{`class="rect"`, `class="textbox"`} {
+		if strings.Contains(s, c) {
+			t.Errorf("SVG(_) has %v, which was not selected:\n%v", c, s)
+		}
+	}
+	if _, err := ParseClasses("textline,bogus"); err == nil {
+		t.Errorf("ParseClasses(bogus)=nil, want error")
+	}
+}
@@ -0,0 +1,185 @@
+// Package explore serves a local web page for exploring the layout of a
+// decoded paystub.
+//
+// The page shows the pages of the paystub as SVG.  Clicking a textline shows
+// its text and bounding box.  Drawing a rectangle shows the textlines that
+// FindInBBox returns for it, and a text query highlights the matching
+// textlines.  This is meant to help with writing the layout rules for a new
+// paystub format, without rerunning the whole conversion for each attempt.
+package explore
+
+import (
+	_ "embed"
+	"encoding/json"
+	goxml "encoding/xml"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/filmil/fintools-public/pkg/draw"
+	"github.com/filmil/fintools-public/pkg/xml"
+	"github.com/golang/glog"
+)
+
+//go:embed index.html
+var indexHTML []byte
+
+// Match kinds for the text queries.
+const (
+	MatchExact  = "exact"
+	MatchPrefix = "prefix"
+	MatchSuffix = "suffix"
+)
+
+// Page describes a page of the paystub.
+type Page struct {
+	ID string `json:"id"`
+	// BBox is the bounding box of the page, in the pdf2txt format.
+	BBox string `json:"bbox"`
+}
+
+// Line describes a textline.
+type Line struct {
+	Text string `json:"text"`
+	// BBox is the bounding box of the textline, in the pdf2txt format.  It is
+	// the same as the data-bbox attribute of the textline in the SVG.
+	BBox string  `json:"bbox"`
+	Font string  `json:"font,omitempty"`
+	Size float64 `json:"size,omitempty"`
+	Bold bool    `json:"bold,omitempty"`
+}
+
+// Server serves the explorer page for a paystub.
+type Server struct {
+	p   xml.Paystub
+	mux *http.ServeMux
+}
+
+// New returns a Server for the paystub.
+func New(p xml.Paystub) *Server {
+	s := &Server{p: p, mux: http.NewServeMux()}
+	s.mux.HandleFunc("/", s.index)
+	s.mux.HandleFunc("/page.svg", s.svg)
+	s.mux.HandleFunc("/api/pages", s.pages)
+	s.mux.HandleFunc("/api/textlines", s.textlines)
+	s.mux.HandleFunc("/api/bbox", s.bbox)
+	s.mux.HandleFunc("/api/query", s.query)
+	return s
+}
+
+// ServeHTTP implements http.Handler.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+func lines(tls []xml.Textline) []Line {
+	r := []Line{}
+	for _, tl := range tls {
+		r = append(r, Line{
+			Text: tl.Text(),
+			BBox: draw.Coords(tl.BBox),
+			Font: tl.Font(),
+			Size: tl.Size(),
+			Bold: tl.Bold(),
+		})
+	}
+	return r
+}
+
+// page returns the page from the "page" form value, counting from 0.
+func (s *Server) page(r *http.Request) (xml.Page, error) {
+	v := r.FormValue("page")
+	if v == "" {
+		v = "0"
+	}
+	i, err := strconv.Atoi(v)
+	if err != nil || i < 0 || i >= len(s.p.Pages) {
+		return xml.Page{}, fmt.Errorf("no page %q, have %d pages", v, len(s.p.Pages))
+	}
+	return s.p.Pages[i], nil
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		glog.Errorf("while writing response: %v", err)
+	}
+}
+
+func (s *Server) index(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/" {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write(indexHTML)
+}
+
+func (s *Server) svg(w http.ResponseWriter, r *http.Request) {
+	p, err := s.page(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "image/svg+xml")
+	if err := draw.SVG(w, []xml.Page{p}, draw.SVGOptions{}); err != nil {
+		glog.Errorf("while writing SVG: %v", err)
+	}
+}
+
+func (s *Server) pages(w http.ResponseWriter, r *http.Request) {
+	ps := []Page{}
+	for _, p := range s.p.Pages {
+		ps = append(ps, Page{ID: p.ID, BBox: draw.Coords(p.BBox)})
+	}
+	writeJSON(w, ps)
+}
+
+func (s *Server) textlines(w http.ResponseWriter, r *http.Request) {
+	p, err := s.page(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	writeJSON(w, lines(xml.Textlines(p)))
+}
+
+// bbox returns the textlines in the bounding box from the "bbox" form value,
+// given as "left,bottom,right,top".
+func (s *Server) bbox(w http.ResponseWriter, r *http.Request) {
+	p, err := s.page(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	var b xml.BBox
+	if err := b.UnmarshalXMLAttr(goxml.Attr{Value: r.FormValue("bbox")}); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	writeJSON(w, lines(xml.FindInBBox(xml.Textlines(p), b)))
+}
+
+// query returns the textlines matching the "text" form value.  The "match"
+// form value is one of MatchExact (the default), MatchPrefix or MatchSuffix.
+func (s *Server) query(w http.ResponseWriter, r *http.Request) {
+	p, err := s.page(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	var f func(string, xml.Textline) bool
+	switch m := r.FormValue("match"); m {
+	case "", MatchExact:
+		f = xml.MatchingText
+	case MatchPrefix:
+		f = xml.MatchingPrefix
+	case MatchSuffix:
+		f = xml.MatchingSuffix
+	default:
+		http.Error(w, fmt.Sprintf("unknown match: %q", m), http.StatusBadRequest)
+		return
+	}
+	tls := xml.MatchPredicate(xml.Textlines(p), xml.BindText(r.FormValue("text"), f))
+	writeJSON(w, lines(tls))
+}
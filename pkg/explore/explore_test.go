@@ -0,0 +1,100 @@
+package explore
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/filmil/fintools-public/pkg/xml"
+	"github.com/google/go-cmp/cmp"
+)
+
+func line(text string, left, bottom float64) xml.Textline {
+	b := xml.BBox{Left: left, Right: left + 4*float64(len(text)), Bottom: bottom, Top: bottom + 8}
+	return xml.Textline{
+		BBox:  b,
+		Texts: []xml.Text{{BBox: b, Font: "ArialMT", Size: 8, T: text}, {T: "\n"}},
+	}
+}
+
+func paystub() xml.Paystub {
+	return xml.Paystub{Pages: []xml.Page{{
+		ID:   "1",
+		BBox: xml.BBox{Left: 0, Bottom: 0, Right: 612, Top: 792},
+		Textboxes: []xml.Textbox{{
+			Textlines: []xml.Textline{
+				line("Pay Date", 40, 700),
+				line("Pay Type", 40, 600),
+				line("Net Pay", 300, 600),
+			},
+		}},
+	}}}
+}
+
+func get(t *testing.T, s *Server, url string, code int) string {
+	t.Helper()
+	w := httptest.NewRecorder()
+	s.ServeHTTP(w, httptest.NewRequest(http.MethodGet, url, nil))
+	if w.Code != code {
+		t.Fatalf("GET %v: code=%v, want: %v\n%v", url, w.Code, code, w.Body.String())
+	}
+	return w.Body.String()
+}
+
+func texts(t *testing.T, body string) []string {
+	t.Helper()
+	var ls []Line
+	if err := json.Unmarshal([]byte(body), &ls); err != nil {
+		t.Fatalf("not JSON lines: %v\n%v", err, body)
+	}
+	var r []string
+	for _, l := range ls {
+		r = append(r, l.Text)
+	}
+	return r
+}
+
+func TestServer(t *testing.T) {
+	t.Parallel()
+	s := New(paystub())
+
+	if b := get(t, s, "/", http.StatusOK); !strings.Contains(b, "<html>") {
+		t.Errorf("GET /: not the index page:\n%v", b)
+	}
+	if b := get(t, s, "/page.svg?page=0", http.StatusOK); !strings.Contains(b, `data-text="Net Pay"`) {
+		t.Errorf("GET /page.svg: no textline:\n%v", b)
+	}
+	get(t, s, "/page.svg?page=1", http.StatusNotFound)
+	get(t, s, "/nothing", http.StatusNotFound)
+
+	tests := []struct {
+		url      string
+		expected []string
+	}{
+		{"/api/textlines?page=0", []string{"Pay Date", "Pay Type", "Net Pay"}},
+		{"/api/bbox?page=0&bbox=0,590,200,650", []string{"Pay Type"}},
+		{"/api/query?page=0&text=Pay+Date", []string{"Pay Date"}},
+		{"/api/query?page=0&text=Pay&match=prefix", []string{"Pay Date", "Pay Type"}},
+		{"/api/query?page=0&text=Pay&match=suffix", []string{"Net Pay"}},
+		{"/api/query?page=0&text=Pay", nil},
+	}
+	for _, test := range tests {
+		actual := texts(t, get(t, s, test.url, http.StatusOK))
+		if diff := cmp.Diff(test.expected, actual); diff != "" {
+			t.Errorf("GET %v=%v, want: %v\ndiff:\n%v", test.url, actual, test.expected, diff)
+		}
+	}
+	get(t, s, "/api/bbox?page=0&bbox=1,2", http.StatusBadRequest)
+	get(t, s, "/api/query?page=0&text=Pay&match=regexp", http.StatusBadRequest)
+
+	var ps []Page
+	if err := json.Unmarshal([]byte(get(t, s, "/api/pages", http.StatusOK)), &ps); err != nil {
+		t.Fatalf("GET /api/pages: %v", err)
+	}
+	expected := []Page{{ID: "1", BBox: "0.000,0.000,612.000,792.000"}}
+	if diff := cmp.Diff(expected, ps); diff != "" {
+		t.Errorf("GET /api/pages=%v, want: %v\ndiff:\n%v", ps, expected, diff)
+	}
+}
@@ -0,0 +1,258 @@
+// Package f1099 parses the consolidated 1099 statements of brokerages from
+// the pdf2txt XML: the 1099-DIV and 1099-INT summary boxes, and the lots sold
+// as reported on the 1099-B.
+//
+// Each form is a section of the statement that starts at its title, such as
+// "Form 1099-DIV", and ends at the title of the next form on the same page,
+// or at the bottom of the page.  A section may continue on the next pages,
+// with the title repeated.
+package f1099
+
+import (
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/filmil/fintools-public/pkg/tx"
+	"github.com/filmil/fintools-public/pkg/xml"
+	"github.com/pkg/errors"
+)
+
+// The forms in a consolidated statement.
+const (
+	FormDIV = "1099-DIV"
+	FormINT = "1099-INT"
+	FormB   = "1099-B"
+)
+
+// Forms are all the forms that Parse reads.
+var Forms = []string{FormDIV, FormINT, FormB}
+
+// Div are the boxes of a 1099-DIV.
+type Div struct {
+	// OrdinaryDividends is box 1a, total ordinary dividends.
+	OrdinaryDividends tx.USD `json:",omitempty"`
+	// QualifiedDividends is box 1b.
+	QualifiedDividends tx.USD `json:",omitempty"`
+	// CapitalGainDistributions is box 2a, total capital gain distributions.
+	CapitalGainDistributions tx.USD `json:",omitempty"`
+	// NondividendDistributions is box 3.
+	NondividendDistributions tx.USD `json:",omitempty"`
+	// FederalIncomeTax is box 4, the federal income tax withheld.
+	FederalIncomeTax tx.USD `json:",omitempty"`
+	// Section199A is box 5, section 199A dividends.
+	Section199A tx.USD `json:",omitempty"`
+	// ForeignTax is box 7, the foreign tax paid.
+	ForeignTax tx.USD `json:",omitempty"`
+}
+
+// Int are the boxes of a 1099-INT.
+type Int struct {
+	// Interest is box 1, interest income.
+	Interest tx.USD `json:",omitempty"`
+	// EarlyWithdrawalPenalty is box 2.
+	EarlyWithdrawalPenalty tx.USD `json:",omitempty"`
+	// Treasury is box 3, interest on U.S. savings bonds and treasury
+	// obligations.
+	Treasury tx.USD `json:",omitempty"`
+	// FederalIncomeTax is box 4, the federal income tax withheld.
+	FederalIncomeTax tx.USD `json:",omitempty"`
+	// ForeignTax is box 6, the foreign tax paid.
+	ForeignTax tx.USD `json:",omitempty"`
+	// TaxExempt is box 8, tax-exempt interest.
+	TaxExempt tx.USD `json:",omitempty"`
+}
+
+// Lot is a lot sold, a row of the 1099-B.
+type Lot struct {
+	// Description of the property, e.g. "100 sh. GOOG".
+	Description string
+	// Acquired is the date acquired, zero if the statement says "Various".
+	Acquired tx.DateOnly `json:",omitempty"`
+	Sold     tx.DateOnly
+	Proceeds tx.USD
+	// Basis is the cost or other basis.
+	Basis tx.USD
+	// WashSale is the wash sale loss disallowed.
+	WashSale tx.USD `json:",omitempty"`
+}
+
+// Gain returns the gain or the loss of the lot, with the disallowed wash sale
+// loss added back.
+func (l Lot) Gain() tx.USD {
+	return tx.Round(l.Proceeds - l.Basis + l.WashSale)
+}
+
+// Statement is a consolidated 1099 statement.
+type Statement struct {
+	// Year is the tax year, zero if not found.
+	Year int   `json:",omitempty"`
+	Div  Div   `json:",omitempty"`
+	Int  Int   `json:",omitempty"`
+	Lots []Lot `json:",omitempty"`
+}
+
+// eps is the slack in the comparison of coordinates.
+const eps = 0.5
+
+// rowTolerance is how far apart vertically the textlines in the same table
+// row may be, in points.
+const rowTolerance = 3
+
+// center returns the vertical center of b.
+func center(b xml.BBox) float64 {
+	return (b.Top + b.Bottom) / 2
+}
+
+// section is a part of a page that holds one form.
+type section struct {
+	form string
+	tls  []xml.Textline
+}
+
+// sections splits the page into the form sections, without the titles.  The
+// textlines above the first title are not in any section.
+func sections(p xml.Page) []section {
+	tls := xml.Textlines(p)
+	type title struct {
+		form        string
+		top, bottom float64
+	}
+	var ts []title
+	for _, f := range Forms {
+		for _, t := range xml.MatchPredicate(tls, xml.Containing("Form "+f)) {
+			ts = append(ts, title{f, t.BBox.Top, t.BBox.Bottom})
+		}
+	}
+	sort.Slice(ts, func(i, j int) bool { return ts[i].top > ts[j].top })
+	var r []section
+	for i, t := range ts {
+		b := p.BBox
+		b.Top = t.bottom
+		if i+1 < len(ts) {
+			b.Bottom = ts[i+1].top + eps
+		}
+		r = append(r, section{form: t.form, tls: xml.MatchPredicate(tls, func(l xml.Textline) bool {
+			c := center(l.BBox)
+			return c <= b.Top && c > b.Bottom
+		})})
+	}
+	return r
+}
+
+// sameRow returns the textlines right of the textline l, in the same row.
+func sameRow(tls []xml.Textline, l xml.Textline) []xml.Textline {
+	return xml.SortLeft(xml.MatchPredicate(tls, func(t xml.Textline) bool {
+		return t.BBox.Left > l.BBox.Right && math.Abs(center(t.BBox)-center(l.BBox)) <= rowTolerance
+	}))
+}
+
+// box returns the amount of the summary box with the label: the rightmost
+// amount in the same row as the label.  ok is false if the label is not
+// there.
+func box(tls []xml.Textline, label string) (v tx.USD, ok bool, err error) {
+	ls := xml.MatchPredicate(tls, xml.Containing(label))
+	if len(ls) == 0 {
+		return 0, false, nil
+	}
+	row := sameRow(tls, xml.SortTop(ls)[0])
+	for i := len(row) - 1; i >= 0; i-- {
+		if v, err := xml.ParseAmount(row[i].Text()); err == nil {
+			return v, true, nil
+		}
+	}
+	return 0, true, errors.Errorf("no amount for %q", label)
+}
+
+// boxes parses the amounts of the labels into the fields.  The labels that
+// are not in the section are left alone, since the section may continue on
+// another page.
+func boxes(tls []xml.Textline, fs []field) error {
+	for _, f := range fs {
+		v, ok, err := box(tls, f.label)
+		if err != nil {
+			return err
+		}
+		if ok {
+			*f.v = v
+		}
+	}
+	return nil
+}
+
+// field is a summary box with its label.
+type field struct {
+	label string
+	v     *tx.USD
+}
+
+func (d *Div) fields() []field {
+	return []field{
+		{"Total Ordinary Dividends", &d.OrdinaryDividends},
+		{"Qualified Dividends", &d.QualifiedDividends},
+		{"Total Capital Gain Distr", &d.CapitalGainDistributions},
+		{"Nondividend Distributions", &d.NondividendDistributions},
+		{"Federal Income Tax Withheld", &d.FederalIncomeTax},
+		{"Section 199A Dividends", &d.Section199A},
+		{"Foreign Tax Paid", &d.ForeignTax},
+	}
+}
+
+func (n *Int) fields() []field {
+	return []field{
+		{"Interest Income", &n.Interest},
+		{"Early Withdrawal Penalty", &n.EarlyWithdrawalPenalty},
+		{"Interest on U.S. Savings Bonds", &n.Treasury},
+		{"Federal Income Tax Withheld", &n.FederalIncomeTax},
+		{"Foreign Tax Paid", &n.ForeignTax},
+		{"Tax-Exempt Interest", &n.TaxExempt},
+	}
+}
+
+// year matches the tax year.
+var year = regexp.MustCompile(`\b(20\d\d)\b`)
+
+// taxYear returns the tax year from the first textline that mentions it,
+// like "Tax Year 2020" or "2020 Form 1099-DIV".
+func taxYear(tls []xml.Textline) int {
+	for _, t := range xml.SortTop(xml.MatchPredicate(tls, func(l xml.Textline) bool {
+		s := strings.ToLower(l.Text())
+		return strings.Contains(s, "tax year") || strings.Contains(s, "form 1099")
+	})) {
+		if m := year.FindStringSubmatch(t.Text()); m != nil {
+			y, _ := strconv.Atoi(m[1])
+			return y
+		}
+	}
+	return 0
+}
+
+// Parse parses the consolidated 1099 statement.
+func Parse(p xml.Paystub) (Statement, error) {
+	var s Statement
+	if len(p.Pages) == 0 {
+		return s, errors.Errorf("no pages")
+	}
+	s.Year = taxYear(xml.Textlines(p.Pages[0]))
+	for i, pg := range p.Pages {
+		for _, sec := range sections(pg) {
+			var err error
+			switch sec.form {
+			case FormDIV:
+				err = boxes(sec.tls, s.Div.fields())
+			case FormINT:
+				err = boxes(sec.tls, s.Int.fields())
+			case FormB:
+				var ls []Lot
+				ls, err = lots(pg, sec.tls)
+				s.Lots = append(s.Lots, ls...)
+			}
+			if err != nil {
+				return s, errors.Wrapf(err, "page %d: while parsing %v", i+1, sec.form)
+			}
+		}
+	}
+	return s, nil
+}
@@ -0,0 +1,120 @@
+package f1099
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/filmil/fintools-public/pkg/tx"
+	"github.com/filmil/fintools-public/pkg/xml"
+	"github.com/google/go-cmp/cmp"
+)
+
+func page(tls ...xml.Textline) xml.Page {
+	return xml.Page{
+		BBox:      xml.BBox{Right: 612, Top: 792},
+		Textboxes: []xml.Textbox{{Textlines: tls}},
+	}
+}
+
+func date(s string) tx.DateOnly {
+	d, err := time.Parse("2006-01-02", s)
+	if err != nil {
+		panic(err)
+	}
+	return tx.DateOnly(d)
+}
+
+// statement is a consolidated statement with all the forms.
+func statement() xml.Paystub {
+	return xml.Paystub{Pages: []xml.Page{
+		page(
+			xml.At("Tax Year 2020 Consolidated Statement", 10, 760),
+			xml.At("Form 1099-DIV Dividends and Distributions", 10, 700),
+			xml.At("1a Total Ordinary Dividends", 10, 680), xml.At("1,234.56", 400, 680),
+			xml.At("1b Qualified Dividends", 10, 670), xml.At("1,000.00", 400, 670),
+			xml.At("4 Federal Income Tax Withheld", 10, 660), xml.At("0.00", 400, 660),
+			xml.At("7 Foreign Tax Paid", 10, 650), xml.At("12.34", 400, 650),
+			xml.At("Form 1099-INT Interest Income", 10, 600),
+			xml.At("1 Interest Income", 10, 580), xml.At("56.78", 400, 580),
+			xml.At("4 Federal Income Tax Withheld", 10, 570), xml.At("5.00", 400, 570),
+		),
+		page(
+			xml.At("Form 1099-B Proceeds From Broker Transactions", 10, 760),
+			xml.At("Short-term transactions for which basis is reported to the IRS", 10, 740),
+			xml.At("Description", 10, 720), xml.At("Date Acquired", 150, 720), xml.At("Date Sold", 230, 720),
+			xml.At("Proceeds", 300, 720), xml.At("Cost Basis", 380, 720), xml.At("Wash Sale", 460, 720),
+			xml.At("ALPHABET INC", 10, 700), xml.At("01/02/2020", 150, 700), xml.At("03/04/2020", 230, 700),
+			xml.At("1,500.00", 300, 700), xml.At("1,200.00", 380, 700),
+			xml.At("CLASS C", 10, 690),
+			xml.At("VANGUARD TOTAL", 10, 675), xml.At("VARIOUS", 150, 675), xml.At("06/01/2020", 230, 675),
+			xml.At("900.00", 300, 675), xml.At("1,000.00", 380, 675), xml.At("50.00", 460, 675),
+			xml.At("Total", 10, 660), xml.At("2,400.00", 300, 660), xml.At("2,200.00", 380, 660),
+		),
+	}}
+}
+
+func TestParse(t *testing.T) {
+	t.Parallel()
+	actual, err := Parse(statement())
+	if err != nil {
+		t.Fatalf("Parse: unexpected error: %v", err)
+	}
+	expected := Statement{
+		Year: 2020,
+		Div:  Div{OrdinaryDividends: 1234.56, QualifiedDividends: 1000, ForeignTax: 12.34},
+		Int:  Int{Interest: 56.78, FederalIncomeTax: 5},
+		Lots: []Lot{
+			{Description: "ALPHABET INC CLASS C", Acquired: date("2020-01-02"), Sold: date("2020-03-04"), Proceeds: 1500, Basis: 1200},
+			{Description: "VANGUARD TOTAL", Sold: date("2020-06-01"), Proceeds: 900, Basis: 1000, WashSale: 50},
+		},
+	}
+	if diff := cmp.Diff(expected, actual, cmp.Comparer(tx.DateOnly.Equal)); diff != "" {
+		t.Errorf("Parse(_)=%+v, want: %+v\ndiff:\n%v", actual, expected, diff)
+	}
+}
+
+func TestOutput(t *testing.T) {
+	t.Parallel()
+	s, err := Parse(statement())
+	if err != nil {
+		t.Fatalf("Parse: unexpected error: %v", err)
+	}
+	var b strings.Builder
+	if err := Beancount(&b, s, "Schwab"); err != nil {
+		t.Fatalf("Beancount: unexpected error: %v", err)
+	}
+	expected := `2020-12-31 custom "1099-div" "Schwab"
+   ordinary-dividends: 1234.5600 USD
+   qualified-dividends: 1000.0000 USD
+   foreign-tax: 12.3400 USD
+2020-12-31 custom "1099-int" "Schwab"
+   interest: 56.7800 USD
+   federal-income-tax: 5.0000 USD
+2020-03-04 custom "1099-b" "Schwab" "ALPHABET INC CLASS C"
+   acquired: 2020-01-02
+   proceeds: 1500.0000 USD
+   cost-basis: 1200.0000 USD
+   gain: 300.0000 USD
+2020-06-01 custom "1099-b" "Schwab" "VANGUARD TOTAL"
+   proceeds: 900.0000 USD
+   cost-basis: 1000.0000 USD
+   wash-sale-disallowed: 50.0000 USD
+   gain: -50.0000 USD
+`
+	if diff := cmp.Diff(expected, b.String()); diff != "" {
+		t.Errorf("Beancount(_)=\n%v\nwant:\n%v\ndiff:\n%v", b.String(), expected, diff)
+	}
+
+	b.Reset()
+	if err := CSV(&b, s.Lots); err != nil {
+		t.Fatalf("CSV: unexpected error: %v", err)
+	}
+	expected = `description,acquired,sold,proceeds,cost_basis,wash_sale_disallowed,gain
+ALPHABET INC CLASS C,2020-01-02,2020-03-04,1500.00,1200.00,0.00,300.00
+VANGUARD TOTAL,various,2020-06-01,900.00,1000.00,50.00,-50.00
+`
+	if diff := cmp.Diff(expected, b.String()); diff != "" {
+		t.Errorf("CSV(_)=\n%v\nwant:\n%v\ndiff:\n%v", b.String(), expected, diff)
+	}
+}
@@ -0,0 +1,198 @@
+package f1099
+
+import (
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/filmil/fintools-public/pkg/tx"
+	"github.com/filmil/fintools-public/pkg/xml"
+	"github.com/pkg/errors"
+)
+
+// The columns of the 1099-B table, and the alternative texts of their
+// headers.
+var (
+	descriptionHeaders = []string{"Description"}
+	acquiredHeaders    = []string{"Date Acquired", "Acquired"}
+	soldHeaders        = []string{"Date Sold", "Sold"}
+	proceedsHeaders    = []string{"Proceeds"}
+	basisHeaders       = []string{"Cost or Other Basis", "Cost Basis", "Basis"}
+	washSaleHeaders    = []string{"Wash Sale Loss Disallowed", "Wash Sale"}
+)
+
+// various is written instead of the date acquired for lots bought on
+// several dates.
+const various = "various"
+
+// header returns the column header with one of the texts that is closest
+// vertically to the anchor, since the words of a header such as "Cost or
+// Other Basis" may also be in the notes above the table.
+func header(tls []xml.Textline, texts []string, anchor xml.Textline) (xml.Textline, bool) {
+	var r xml.Textline
+	found := false
+	for _, t := range texts {
+		for _, h := range xml.MatchPredicate(tls, xml.Containing(t)) {
+			d := math.Abs(center(h.BBox) - center(anchor.BBox))
+			if !found || d < math.Abs(center(r.BBox)-center(anchor.BBox)) {
+				r, found = h, true
+			}
+		}
+		if found {
+			return r, true
+		}
+	}
+	return r, false
+}
+
+// rows returns the y coordinates that separate the table rows of the
+// textlines, top down.  Textlines whose centers are within rowTolerance of
+// each other are in the same row.
+func rows(tls []xml.Textline) []float64 {
+	tls = xml.SortTop(append([]xml.Textline{}, tls...))
+	var ys []float64
+	var c, bottom float64
+	for i, t := range tls {
+		switch {
+		case i == 0:
+		case c-center(t.BBox) > rowTolerance:
+			ys = append(ys, (bottom+t.BBox.Top)/2)
+		default:
+			if t.BBox.Bottom < bottom {
+				bottom = t.BBox.Bottom
+			}
+			continue
+		}
+		c, bottom = center(t.BBox), t.BBox.Bottom
+	}
+	if len(tls) > 0 {
+		ys = append(ys, bottom-eps)
+	}
+	return ys
+}
+
+// grid returns the grid of the table with the headers.  The rules drawn on
+// the page are used if there are enough of them, otherwise the columns are
+// split halfway between the header centers, and the rows between the
+// textlines.
+func grid(p xml.Page, tls []xml.Textline, headers []xml.Textline) xml.Grid {
+	var top, bottom float64
+	var xs []float64
+	for i, h := range headers {
+		if i == 0 || h.BBox.Top > top {
+			top = h.BBox.Top
+		}
+		if i == 0 || h.BBox.Bottom < bottom {
+			bottom = h.BBox.Bottom
+		}
+		xs = append(xs, (h.BBox.Left+h.BBox.Right)/2)
+	}
+	b := p.BBox
+	b.Top = top + eps
+	if g := xml.DetectGrid(p, b); g.Cols() >= len(headers) && g.Rows() >= 2 {
+		return g
+	}
+	sort.Float64s(xs)
+	g := xml.Grid{Xs: []float64{p.BBox.Left}}
+	for i := 1; i < len(xs); i++ {
+		g.Xs = append(g.Xs, (xs[i-1]+xs[i])/2)
+	}
+	g.Xs = append(g.Xs, p.BBox.Right)
+	var body []xml.Textline
+	for _, t := range tls {
+		if center(t.BBox) < bottom {
+			body = append(body, t)
+		}
+	}
+	g.Ys = append([]float64{top + eps, bottom - eps}, rows(body)...)
+	return g
+}
+
+// lots parses the 1099-B lots from the textlines of a section on page p.
+// Rows without a sale date are not lots: a text-only row continues the
+// description of the lot above, and the others are totals.
+func lots(p xml.Page, tls []xml.Textline) ([]Lot, error) {
+	// The header row has both the proceeds and the date sold.
+	var proceeds xml.Textline
+	found := false
+	dist := 0.0
+	for _, c := range xml.MatchPredicate(tls, xml.Containing(proceedsHeaders[0])) {
+		s, ok := header(tls, soldHeaders, c)
+		if !ok {
+			continue
+		}
+		d := math.Abs(center(s.BBox) - center(c.BBox))
+		if !found || d < dist {
+			proceeds, found, dist = c, true, d
+		}
+	}
+	if !found || dist > rowTolerance*3 {
+		// The 1099-B summary, or the notes.
+		return nil, nil
+	}
+	cols := [][]string{descriptionHeaders, acquiredHeaders, soldHeaders, proceedsHeaders, basisHeaders, washSaleHeaders}
+	var headers []xml.Textline
+	for i, c := range cols {
+		h, ok := header(tls, c, proceeds)
+		if !ok && i < len(cols)-1 {
+			return nil, errors.Errorf("no column %q", c[0])
+		}
+		if ok {
+			headers = append(headers, h)
+		}
+	}
+	g := grid(p, tls, headers)
+	var cells [][]xml.Cell
+	for _, h := range headers {
+		c, err := g.ColumnBelow(tls, h)
+		if err != nil {
+			return nil, err
+		}
+		cells = append(cells, c)
+	}
+	var r []Lot
+	continued := false
+	for i := range cells[0] {
+		text := func(col int) string {
+			if col >= len(cells) {
+				return ""
+			}
+			return strings.TrimSpace(cells[col][i].Text())
+		}
+		sold := text(2)
+		if sold == "" {
+			if d := text(0); d != "" && continued && text(3) == "" {
+				r[len(r)-1].Description += " " + d
+				continue
+			}
+			continued = false
+			continue
+		}
+		var l Lot
+		var err error
+		l.Description = text(0)
+		if l.Sold, err = xml.USDate(sold); err != nil {
+			return nil, errors.Wrapf(err, "lot %q: date sold", l.Description)
+		}
+		if a := text(1); !strings.EqualFold(a, various) && a != "" {
+			if l.Acquired, err = xml.USDate(a); err != nil {
+				return nil, errors.Wrapf(err, "lot %q: date acquired", l.Description)
+			}
+		}
+		for _, f := range []struct {
+			col int
+			v   *tx.USD
+		}{{3, &l.Proceeds}, {4, &l.Basis}, {5, &l.WashSale}} {
+			s := text(f.col)
+			if s == "" || s == "..." {
+				continue
+			}
+			if *f.v, err = xml.ParseAmount(s); err != nil {
+				return nil, errors.Wrapf(err, "lot %q", l.Description)
+			}
+		}
+		r = append(r, l)
+		continued = true
+	}
+	return r, nil
+}
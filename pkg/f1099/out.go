@@ -0,0 +1,110 @@
+package f1099
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"text/template"
+	"time"
+
+	"github.com/filmil/fintools-public/pkg/tx"
+)
+
+func ymd(d tx.DateOnly) string {
+	return time.Time(d).Format("2006-01-02")
+}
+
+// meta is a beancount metadata entry.
+type meta struct {
+	Key   string
+	Value tx.USD
+}
+
+// metas returns the metadata for the nonzero fields.
+func metas(fs []field, keys []string) []meta {
+	var r []meta
+	for i, f := range fs {
+		if *f.v != 0 {
+			r = append(r, meta{keys[i], *f.v})
+		}
+	}
+	return r
+}
+
+// The metadata keys of the boxes, in the order of Div.fields and Int.fields.
+var (
+	divKeys = []string{"ordinary-dividends", "qualified-dividends", "capital-gain-distributions",
+		"nondividend-distributions", "federal-income-tax", "section-199a-dividends", "foreign-tax"}
+	intKeys = []string{"interest", "early-withdrawal-penalty", "treasury-interest",
+		"federal-income-tax", "foreign-tax", "tax-exempt-interest"}
+)
+
+type beancount struct {
+	S     Statement
+	Payer string
+	End   string
+	Div   []meta
+	Int   []meta
+}
+
+var beancountTpl = template.Must(template.New("1099").Funcs(template.FuncMap{
+	"ymd": ymd,
+}).Parse(`{{if .Div}}{{.End}} custom "1099-div" {{printf "%q" .Payer}}{{range .Div}}
+   {{.Key}}: {{.Value}}{{end}}
+{{end}}{{if .Int}}{{.End}} custom "1099-int" {{printf "%q" .Payer}}{{range .Int}}
+   {{.Key}}: {{.Value}}{{end}}
+{{end}}{{range .S.Lots}}{{ymd .Sold}} custom "1099-b" {{printf "%q" $.Payer}} {{printf "%q" .Description}}{{if not .Acquired.IsZero}}
+   acquired: {{ymd .Acquired}}{{end}}
+   proceeds: {{.Proceeds}}
+   cost-basis: {{.Basis}}{{if .WashSale}}
+   wash-sale-disallowed: {{.WashSale}}{{end}}
+   gain: {{.Gain}}
+{{end}}`))
+
+// Beancount writes the statement as beancount custom entries: a "1099-div"
+// and a "1099-int" entry with the nonzero boxes as metadata on the last day
+// of the tax year, and a "1099-b" entry for each lot on the day it was sold.
+// The lots can then be matched to the sales in the ledger by date and by
+// amount.
+func Beancount(w io.Writer, s Statement, payer string) error {
+	if s.Year == 0 {
+		return fmt.Errorf("the statement has no tax year")
+	}
+	b := beancount{
+		S:     s,
+		Payer: payer,
+		End:   fmt.Sprintf("%d-12-31", s.Year),
+		Div:   metas(s.Div.fields(), divKeys),
+		Int:   metas(s.Int.fields(), intKeys),
+	}
+	return beancountTpl.Execute(w, b)
+}
+
+// CSVHeader is the header row of the CSV output.
+var CSVHeader = []string{"description", "acquired", "sold", "proceeds", "cost_basis", "wash_sale_disallowed", "gain"}
+
+// CSV writes the lots as CSV, with the header row.  The date acquired of the
+// lots bought on several dates is "various".
+func CSV(w io.Writer, ls []Lot) error {
+	c := csv.NewWriter(w)
+	if err := c.Write(CSVHeader); err != nil {
+		return err
+	}
+	amount := func(v tx.USD) string {
+		return fmt.Sprintf("%.2f", v)
+	}
+	for _, l := range ls {
+		a := various
+		if !l.Acquired.IsZero() {
+			a = ymd(l.Acquired)
+		}
+		if err := c.Write([]string{
+			l.Description, a, ymd(l.Sold),
+			amount(l.Proceeds), amount(l.Basis), amount(l.WashSale), amount(l.Gain()),
+		}); err != nil {
+			return err
+		}
+	}
+	c.Flush()
+	return c.Error()
+}
@@ -0,0 +1,422 @@
+// Package gen generates synthetic paystubs in the format that pdf2txt
+// produces.  The textlines are placed the way they are on a real paystub, so
+// that the generated paystubs can be used to test the parser without any real
+// paystub data.
+package gen
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/filmil/fintools-public/pkg/tx"
+	"github.com/filmil/fintools-public/pkg/xml"
+)
+
+const (
+	// The page is US letter, in points.
+	pageWidth  = 612
+	pageHeight = 792
+
+	// Text metrics.  All text is set in a fixed width font.
+	fontName  = "ArialMT"
+	boldName  = "Arial-BoldMT"
+	fontSize  = 8
+	charWidth = 4
+	// lineHeight is the vertical distance between two textlines in a row.
+	lineHeight = 10
+	// rowHeight is the vertical distance between two table rows.
+	rowHeight = 2 * lineHeight
+
+	// Left edges of the columns.
+	leftMargin       = 40
+	valueLeft        = 120
+	earningsCurrent  = 230
+	earningsYTD      = 280
+	dedsLeft         = 330
+	employeeCurrent  = 430
+	employeeYTD      = 475
+	employerCurrent  = 520
+	employerYTD      = 565
+	netPayLeft       = 400
+	netPayAmountLeft = 470
+	// The second column of the header.
+	headerLeft      = 230
+	headerValueLeft = 310
+	// The columns of the withholding settings.
+	w4Federal = 160
+	w4State   = 280
+)
+
+// kindTitles are the paystub titles for the paystub kinds.
+var kindTitles = map[string]string{
+	tx.KindReversal:   "Reversal",
+	tx.KindCorrection: "Correction",
+	tx.KindOffCycle:   "Off-Cycle Payment",
+}
+
+// Options modify the generated paystub.
+type Options struct {
+	// WrapLabels, if set, splits the labels that have more than one word
+	// into two textlines, with the amounts next to the first one.
+	WrapLabels bool
+	// MergeYTDCurrent, if set, merges the "YTD" header of the employee
+	// deductions with the "Current" header of the employer deductions into a
+	// single textline, as pdf2txt sometimes does.
+	MergeYTDCurrent bool
+	// Omit are the parts of the paystub to leave out: any of the tx.Section*
+	// sections, PaidTimeOff or TotalHoursWorked.  Leaving out
+	// tx.SectionDeductions also leaves out tx.SectionEmployer.
+	Omit []string
+}
+
+// Parts of the paystub that are not sections of a transaction, but can be
+// left out with Options.Omit.
+const (
+	PaidTimeOff      = "Paid Time Off"
+	TotalHoursWorked = "Total Hours Worked"
+)
+
+func (o Options) has(part string) bool {
+	for _, p := range o.Omit {
+		if p == part || (p == tx.SectionDeductions && part == tx.SectionEmployer) {
+			return false
+		}
+	}
+	return true
+}
+
+// generator accumulates the textlines of a page.
+type generator struct {
+	o   Options
+	tbs []xml.Textbox
+}
+
+// Google generates a paystub in the Google layout containing the data from t.
+func Google(t tx.Transaction, o Options) xml.Paystub {
+	g := generator{o: o}
+	var earnings, deductions, taxes, employer []tx.Item
+	for _, i := range t.Items() {
+		switch i.Section {
+		case tx.SectionEarnings:
+			earnings = append(earnings, i)
+		case tx.SectionDeductions:
+			deductions = append(deductions, i)
+		case tx.SectionTaxes:
+			taxes = append(taxes, i)
+		case tx.SectionEmployer:
+			employer = append(employer, i)
+		}
+	}
+
+	// Header.
+	y := float64(750)
+	g.bold(leftMargin, y, "Pay Statement")
+	if title := kindTitles[t.Kind]; title != "" {
+		g.bold(headerLeft, y, title)
+	}
+	y -= 15
+	g.text(leftMargin, y, "Pay Date")
+	g.text(valueLeft, y, time.Time(t.Date).Format("01/02/2006"))
+	y -= 12
+	g.text(leftMargin, y, "Document")
+	g.text(valueLeft, y, t.DocNum)
+	y -= 12
+	if !t.CheckDate.IsZero() {
+		g.text(leftMargin, y, "Check Date")
+		g.text(valueLeft, y, date(t.CheckDate))
+	}
+	y -= 12
+	if t.EmployeeID != "" {
+		g.text(leftMargin, y, "Employee ID")
+		g.text(valueLeft, y, t.EmployeeID)
+	}
+	y -= 12
+	if t.Adjusts != "" {
+		g.text(leftMargin, y, "Original Document")
+		g.text(valueLeft, y, t.Adjusts)
+	}
+	// The optional header fields in the second column, which starts below
+	// the document number: the pay date and the document number must be the
+	// only values on their lines.
+	y = 711
+	for _, f := range []struct{ label, value string }{
+		{"Company", t.Company},
+		{"Department", t.Department},
+		{"Pay Period Begin", date(t.PayPeriodStart)},
+		{"Pay Period End", date(t.PayPeriodEnd)},
+		{"Pay Rate", rate(t.PayRate)},
+	} {
+		if f.value != "" {
+			g.text(headerLeft, y, f.label)
+			g.text(headerValueLeft, y, f.value)
+		}
+		y -= lineHeight
+	}
+	g.bold(netPayLeft, 700, "Net Pay")
+	g.text(netPayAmountLeft, 700, Dollars(t.NetPay))
+
+	// Earnings, with deductions to the right of it.
+	y = 660
+	hasEarnings, hasDeductions, hasEmployer := o.has(tx.SectionEarnings), o.has(tx.SectionDeductions), o.has(tx.SectionEmployer)
+	if hasEarnings {
+		g.bold(leftMargin, y, "Earnings")
+		g.text(leftMargin, y-15, "Pay Type")
+		g.text(earningsCurrent, y-15, "Current")
+		g.text(earningsYTD, y-15, "YTD")
+	}
+	if hasDeductions {
+		g.bold(dedsLeft, y, "Deductions")
+		g.text(employeeCurrent, y-8, "Employee")
+		g.text(dedsLeft, y-15, "Deduction")
+		g.text(employeeCurrent, y-15, "Current")
+		switch {
+		case !hasEmployer:
+			g.text(employeeYTD, y-15, "YTD")
+		case g.o.MergeYTDCurrent:
+			g.text(employeeYTD, y-15, "YTD"+strings.Repeat(" ", (employerCurrent-employeeYTD)/charWidth-3)+"Current")
+		default:
+			g.text(employeeYTD, y-15, "YTD")
+			g.text(employerCurrent, y-15, "Current")
+		}
+		if hasEmployer {
+			g.text(employerCurrent, y-8, "Employer")
+			g.text(employerYTD, y-15, "YTD")
+		}
+	}
+	y -= 15 + rowHeight
+
+	ey := y
+	if hasEarnings {
+		for _, i := range earnings {
+			g.label(leftMargin, ey, i.Label)
+			g.amount(earningsCurrent, ey, i.Amount)
+			g.amount(earningsYTD, ey, i.Amount)
+			ey -= rowHeight
+		}
+		if o.has(TotalHoursWorked) {
+			g.text(leftMargin, ey, "Total Hours Worked")
+			ey -= rowHeight
+		}
+	}
+
+	// Every deduction row has both the employee and the employer amounts.
+	dy := y
+	if hasDeductions {
+		if !hasEmployer {
+			employer = nil
+		}
+		for _, l := range mergeLabels(deductions, employer) {
+			ee, er := amountOf(deductions, l), amountOf(employer, l)
+			g.label(dedsLeft, dy, l)
+			g.amount(employeeCurrent, dy, ee)
+			g.amount(employeeYTD, dy, ee)
+			if hasEmployer {
+				g.amount(employerCurrent, dy, er)
+				g.amount(employerYTD, dy, er)
+			}
+			dy -= rowHeight
+		}
+	}
+
+	// Taxes, below both earnings and deductions.
+	y = math.Min(ey, dy) - rowHeight
+	if o.has(tx.SectionTaxes) {
+		g.bold(leftMargin, y, "Taxes")
+		y -= 15
+		g.text(leftMargin, y, "Tax")
+		g.text(earningsCurrent, y, "Current")
+		g.text(earningsYTD, y, "YTD")
+		y -= rowHeight
+		for _, i := range taxes {
+			g.label(leftMargin, y, i.Label)
+			g.amount(earningsCurrent, y, i.Amount)
+			g.amount(earningsYTD, y, i.Amount)
+			y -= rowHeight
+		}
+	}
+
+	y -= rowHeight
+	if o.has(PaidTimeOff) {
+		g.bold(leftMargin, y, "Paid Time Off")
+	}
+
+	if !t.W4.IsZero() {
+		w := t.W4
+		y -= 3 * rowHeight
+		g.bold(leftMargin, y, "Tax Withholding Information")
+		y -= 15
+		g.text(w4Federal, y, "Federal")
+		g.text(w4State, y, "State")
+		for _, r := range []struct{ label, federal, state string }{
+			{"Filing Status", w.FederalFilingStatus, w.StateFilingStatus},
+			{"Allowances", fmt.Sprint(w.FederalAllowances), fmt.Sprint(w.StateAllowances)},
+			{"Additional Withholding", Amount(w.FederalExtra), Amount(w.StateExtra)},
+		} {
+			y -= lineHeight + 2
+			g.text(leftMargin, y, r.label)
+			if r.federal != "" {
+				g.text(w4Federal, y, r.federal)
+			}
+			if r.state != "" {
+				g.text(w4State, y, r.state)
+			}
+		}
+	}
+
+	// Only the first vest is listed, the paystub has room for one.
+	if len(t.Vests) > 0 {
+		v := t.Vests[0]
+		y -= 3 * rowHeight
+		g.bold(leftMargin, y, "Stock Vest Details")
+		y -= 3
+		for _, r := range [][2]string{
+			{"Vest Date", date(v.Date)},
+			{"Symbol", v.Symbol},
+			{"Shares Vested", shares(v.Shares)},
+			{"Shares Withheld", shares(v.WithheldShares)},
+			{"Vest FMV", Dollars(v.FMV)},
+		} {
+			if r[1] == "" {
+				continue
+			}
+			y -= lineHeight + 2
+			g.text(leftMargin, y, r[0])
+			g.text(w4Federal, y, r[1])
+		}
+	}
+
+	page := xml.Page{
+		ID:        "1",
+		BBox:      xml.BBox{Left: 0, Bottom: 0, Right: pageWidth, Top: pageHeight},
+		Textboxes: g.tbs,
+	}
+	return xml.Paystub{Pages: []xml.Page{page}}
+}
+
+// Amount formats v the way amounts are written on a paystub, for example
+// "1,234.56", or "(10.00)" when negative.
+func Amount(v tx.USD) string {
+	cents := int64(math.Round(math.Abs(float64(v)) * 100))
+	d := fmt.Sprintf("%d", cents/100)
+	var b strings.Builder
+	for i, c := range d {
+		if i > 0 && (len(d)-i)%3 == 0 {
+			b.WriteRune(',')
+		}
+		b.WriteRune(c)
+	}
+	s := fmt.Sprintf("%s.%02d", b.String(), cents%100)
+	if v < 0 {
+		s = "(" + s + ")"
+	}
+	return s
+}
+
+// Dollars formats v like Amount does, but with a dollar sign, for example
+// "$1,234.56", or "($10.00)" when negative.
+func Dollars(v tx.USD) string {
+	s := Amount(v)
+	if v < 0 {
+		return "($" + s[1:]
+	}
+	return "$" + s
+}
+
+// date formats d the way dates are written on a paystub, or returns "" if d
+// is not set.
+func date(d tx.DateOnly) string {
+	if d.IsZero() {
+		return ""
+	}
+	return time.Time(d).Format("01/02/2006")
+}
+
+// rate formats the pay rate, or returns "" if it is not set.
+func rate(v tx.USD) string {
+	if v == 0 {
+		return ""
+	}
+	return Dollars(v)
+}
+
+// shares formats a share count.
+func shares(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+// mergeLabels returns the labels of a and the labels of b that are not in a,
+// in order.
+func mergeLabels(a, b []tx.Item) []string {
+	var r []string
+	seen := map[string]bool{}
+	for _, is := range [][]tx.Item{a, b} {
+		for _, i := range is {
+			if !seen[i.Label] {
+				seen[i.Label] = true
+				r = append(r, i.Label)
+			}
+		}
+	}
+	return r
+}
+
+// amountOf returns the amount for label l in items, or zero.
+func amountOf(items []tx.Item, l string) tx.USD {
+	for _, i := range items {
+		if i.Label == l {
+			return i.Amount
+		}
+	}
+	return 0
+}
+
+// label adds a row label.  If wrapping is enabled, labels of more than one word
+// are split over two lines.
+func (g *generator) label(x, y float64, l string) {
+	w := strings.Fields(l)
+	if !g.o.WrapLabels || len(w) < 2 {
+		g.text(x, y, l)
+		return
+	}
+	h := len(w) / 2
+	g.text(x, y, strings.Join(w[:h], " "))
+	g.text(x, y-lineHeight, strings.Join(w[h:], " "))
+}
+
+func (g *generator) amount(x, y float64, v tx.USD) {
+	g.text(x, y, Amount(v))
+}
+
+func (g *generator) text(x, y float64, s string) {
+	g.add(x, y, s, fontName)
+}
+
+func (g *generator) bold(x, y float64, s string) {
+	g.add(x, y, s, boldName)
+}
+
+// add adds a textbox with a single textline containing s, with the lower left
+// corner at (x, y).
+func (g *generator) add(x, y float64, s, font string) {
+	tl := xml.Textline{BBox: xml.BBox{Left: x, Bottom: y, Top: y + fontSize}}
+	for _, c := range s {
+		t := xml.Text{T: string(c)}
+		if c != ' ' {
+			t.Font = font
+			t.Size = fontSize
+			t.BBox = xml.BBox{Left: x, Right: x + charWidth, Bottom: y, Top: y + fontSize}
+		}
+		tl.Texts = append(tl.Texts, t)
+		x += charWidth
+	}
+	tl.BBox.Right = x
+	tl.Texts = append(tl.Texts, xml.Text{T: "\n"})
+	g.tbs = append(g.tbs, xml.Textbox{
+		ID:        len(g.tbs),
+		BBox:      tl.BBox,
+		Textlines: []xml.Textline{tl},
+	})
+}
@@ -0,0 +1,230 @@
+package gen
+
+import (
+	"bytes"
+	"fmt"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/filmil/fintools-public/pkg/tx"
+	"github.com/filmil/fintools-public/pkg/xml"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestAmount(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		v        tx.USD
+		expected string
+	}{
+		{0, "0.00"},
+		{0.5, "0.50"},
+		{999.99, "999.99"},
+		{1000, "1,000.00"},
+		{1234567.89, "1,234,567.89"},
+		{-10, "(10.00)"},
+	}
+	for _, test := range tests {
+		if actual := Amount(test.v); actual != test.expected {
+			t.Errorf("Amount(%v)=%q, want: %q", test.v, actual, test.expected)
+		}
+	}
+}
+
+// randomAmount returns a random amount under 100,000, which may be negative.
+func randomAmount(r *rand.Rand) tx.USD {
+	v := tx.USD(r.Int63n(10000000)) / 100
+	if r.Intn(10) == 0 {
+		v = -v
+	}
+	return v
+}
+
+// randomTransaction returns a transaction with a random subset of the known
+// line items filled in.
+func randomTransaction(r *rand.Rand) tx.Transaction {
+	var t tx.Transaction
+	t.Date = tx.DateOnly(time.Date(2000+r.Intn(30), time.Month(1+r.Intn(12)), 1+r.Intn(28), 0, 0, 0, 0, time.UTC))
+	t.DocNum = fmt.Sprintf("%08d", r.Intn(100000000))
+	t.NetPay = randomAmount(r)
+	// The optional header fields.
+	if r.Intn(2) == 0 {
+		t.PayPeriodStart = tx.DateOnly(time.Time(t.Date).AddDate(0, 0, -20))
+		t.PayPeriodEnd = tx.DateOnly(time.Time(t.Date).AddDate(0, 0, -6))
+	}
+	if r.Intn(2) == 0 {
+		t.CheckDate = t.Date
+	}
+	if r.Intn(2) == 0 {
+		t.EmployeeID = fmt.Sprintf("%06d", r.Intn(1000000))
+		t.Department = "Engineering"
+		t.Company = "Google LLC"
+	}
+	if r.Intn(2) == 0 {
+		statuses := []string{"Single", "Married", "Married filing jointly", "Head of Household"}
+		t.W4 = tx.W4{
+			FederalFilingStatus: statuses[r.Intn(len(statuses))],
+			FederalAllowances:   r.Intn(5),
+			FederalExtra:        tx.USD(r.Intn(3) * 25),
+			StateFilingStatus:   statuses[r.Intn(len(statuses))],
+			StateAllowances:     r.Intn(5),
+			StateExtra:          tx.USD(r.Intn(3) * 10),
+		}
+	}
+	switch r.Intn(6) {
+	case 0:
+		t.Kind = tx.KindOffCycle
+	case 1:
+		t.Kind = tx.KindCorrection
+		t.Adjusts = fmt.Sprintf("%08d", r.Intn(100000000))
+	case 2:
+		t.Kind = tx.KindReversal
+		t.Adjusts = fmt.Sprintf("%08d", r.Intn(100000000))
+	}
+	if r.Intn(2) == 0 {
+		t.PayRate = tx.USD(r.Int63n(30000000)) / 100
+	}
+	set := map[string]func(string, tx.USD) error{
+		tx.SectionEarnings:   t.IncomeByName,
+		tx.SectionDeductions: t.ExpenseByName,
+		tx.SectionTaxes:      t.ExpenseByName,
+		tx.SectionEmployer:   t.EmployerExpenseByName,
+	}
+	for _, s := range tx.Sections {
+		for _, l := range tx.Labels(s) {
+			if r.Intn(3) != 0 {
+				continue
+			}
+			if err := set[s](l, randomAmount(r)); err != nil {
+				panic(err)
+			}
+		}
+	}
+	if r.Intn(3) == 0 {
+		t.Vests = []tx.Vest{{
+			Date:           tx.DateOnly(time.Time(t.Date).AddDate(0, 0, -r.Intn(10))),
+			Symbol:         "GOOG",
+			Shares:         float64(1+r.Intn(100000)) / 1000,
+			WithheldShares: float64(r.Intn(40000)) / 1000,
+			FMV:            tx.USD(1+r.Int63n(300000)) / 100,
+		}}
+	}
+	// A reversal takes the money back, see xml.Convert.
+	if t.Kind == tx.KindReversal {
+		t.Reverse()
+	}
+	return t
+}
+
+// clearSection sets all amounts in the section of t to zero.
+func clearSection(t *tx.Transaction, section string) {
+	var us []tx.Unknown
+	for _, u := range t.Unknown {
+		if u.Section != section {
+			us = append(us, u)
+		}
+	}
+	t.Unknown = us
+	add := map[string]func(string, tx.USD) error{
+		tx.SectionEarnings:   t.IncomeByName,
+		tx.SectionDeductions: t.ExpenseByName,
+		tx.SectionTaxes:      t.ExpenseByName,
+		tx.SectionEmployer:   t.EmployerExpenseByName,
+	}[section]
+	for _, it := range t.Items() {
+		if it.Section != section {
+			continue
+		}
+		if err := add(it.Label, -it.Amount); err != nil {
+			// Items only returns the known labels of the section.
+			panic(err)
+		}
+	}
+}
+
+// omit returns random parts of the paystub to leave out, and removes the
+// items in the sections left out from t.
+func omit(r *rand.Rand, t *tx.Transaction) []string {
+	var o []string
+	for _, p := range []string{tx.SectionEarnings, tx.SectionDeductions, tx.SectionEmployer, tx.SectionTaxes, PaidTimeOff, TotalHoursWorked} {
+		if r.Intn(3) == 0 {
+			o = append(o, p)
+		}
+	}
+	missing := map[string]bool{}
+	for _, p := range o {
+		missing[p] = true
+	}
+	if missing[tx.SectionDeductions] {
+		missing[tx.SectionEmployer] = true
+	}
+	for _, s := range tx.Sections {
+		if missing[s] {
+			clearSection(t, s)
+			t.Missing = append(t.Missing, s)
+		}
+	}
+	return o
+}
+
+func TestGoogleRoundTrip(t *testing.T) {
+	t.Parallel()
+	r := rand.New(rand.NewSource(42))
+	for i := 0; i < 200; i++ {
+		expected := randomTransaction(r)
+		o := Options{WrapLabels: r.Intn(2) == 0, MergeYTDCurrent: r.Intn(2) == 0}
+		if r.Intn(3) == 0 {
+			o.Omit = omit(r, &expected)
+		}
+		t.Run(fmt.Sprintf("%d:%+v", i, o), func(t *testing.T) {
+			var b bytes.Buffer
+			if err := xml.Encode(&b, Google(expected, o)); err != nil {
+				t.Fatalf("Encode: unexpected error: %v", err)
+			}
+			actual, err := xml.Parse(&b)
+			if err != nil {
+				t.Fatalf("Parse: unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(expected, actual, cmp.Comparer(tx.DateOnly.Equal)); diff != "" {
+				t.Errorf("Parse(Google(%+v))=%+v\ndiff:\n%v", expected, actual, diff)
+			}
+		})
+	}
+}
+
+func TestGoogleHeaderLikeLabel(t *testing.T) {
+	t.Parallel()
+	expected := tx.Transaction{RegularPay: 100, NetPay: 95}
+	// A line item with the same text as the section header below it.
+	expected.UnknownByName(tx.SectionEarnings, "Taxes", 5)
+	var b bytes.Buffer
+	if err := xml.Encode(&b, Google(expected, Options{})); err != nil {
+		t.Fatalf("Encode: unexpected error: %v", err)
+	}
+	actual, err := xml.ParseWithOptions(&b, xml.Options{Lenient: true})
+	if err != nil {
+		t.Fatalf("Parse: unexpected error: %v", err)
+	}
+	if diff := cmp.Diff(expected, actual, cmp.Comparer(tx.DateOnly.Equal)); diff != "" {
+		t.Errorf("Parse(Google(%+v))=%+v\ndiff:\n%v", expected, actual, diff)
+	}
+}
+
+func TestGoogleBonusOnly(t *testing.T) {
+	t.Parallel()
+	expected := tx.Transaction{AnnualBonus: 1000, NetPay: 1000}
+	o := Options{Omit: []string{tx.SectionDeductions, tx.SectionTaxes, PaidTimeOff, TotalHoursWorked}}
+	var b bytes.Buffer
+	if err := xml.Encode(&b, Google(expected, o)); err != nil {
+		t.Fatalf("Encode: unexpected error: %v", err)
+	}
+	actual, err := xml.Parse(&b)
+	if err != nil {
+		t.Fatalf("Parse: unexpected error: %v", err)
+	}
+	expected.Missing = []string{tx.SectionDeductions, tx.SectionTaxes, tx.SectionEmployer}
+	if diff := cmp.Diff(expected, actual, cmp.Comparer(tx.DateOnly.Equal)); diff != "" {
+		t.Errorf("Parse(Google(%+v))=%+v\ndiff:\n%v", expected, actual, diff)
+	}
+}
@@ -0,0 +1,215 @@
+package limits
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/filmil/fintools-public/pkg/tx"
+)
+
+// Other are the totals of a year from an employer whose paystubs are not at
+// hand, e.g. from its W-2.
+type Other struct {
+	Year     int
+	Employer string
+	Totals
+}
+
+// Options modify the checks.
+type Options struct {
+	// CatchUp allows the catch-up deferrals, for an employee who is 50 or
+	// older at the end of the year.
+	CatchUp bool
+	// Other are the totals from the other employers.
+	Other []Other
+}
+
+// employer names the employer in the warnings.
+func employer(name string) string {
+	if name == "" {
+		return "the employer"
+	}
+	return fmt.Sprintf("%q", name)
+}
+
+// over returns a warning if the total so far or at the end of the year is
+// over the limit, or an empty string if it is not.
+func over(year int, what string, ytd, end, limit tx.USD, name string) string {
+	switch {
+	case tx.Round(ytd) > limit:
+		return fmt.Sprintf("%v: %v of %.2f are over the %v limit of %.2f by %.2f",
+			year, what, ytd, name, limit, ytd-limit)
+	case tx.Round(end) > limit:
+		return fmt.Sprintf("%v: %v are projected to reach %.2f by the end of the year, over the %v limit of %.2f by %.2f",
+			year, what, end, name, limit, end-limit)
+	}
+	return ""
+}
+
+// capped returns the total at the end of the year, given that the employer
+// stops withholding or deferring at the maximum, e.g. the social security tax
+// at its maximum or the 401(k) elective deferrals at the 402(g) limit.
+func capped(ytd, end, max tx.USD) tx.USD {
+	if ytd >= max {
+		return ytd
+	}
+	return tx.USD(math.Min(float64(end), float64(max)))
+}
+
+// missedMatch returns a warning if the elective deferrals reach the 402(g)
+// limit before the last payday of the year.  Many plans match each paycheck,
+// so the paychecks after that get no match, unless the plan trues it up at
+// the end of the year.  Before is the amount deferred at other employers.
+func missedMatch(p Projection, before, limit tx.USD) string {
+	if p.YTD.Match == 0 {
+		return ""
+	}
+	sum := before
+	var reached tx.DateOnly
+	left := 0
+	add := func(d tx.DateOnly, elective tx.USD, payday bool) {
+		if !reached.IsZero() {
+			if payday {
+				left++
+			}
+			return
+		}
+		sum += elective
+		if tx.Round(sum) >= limit {
+			reached = d
+		}
+	}
+	for _, t := range p.Paystubs {
+		add(t.Date, t.Bonus401kPre, regular(t))
+	}
+	for _, d := range p.Paydays {
+		add(d, p.PerPayday.Elective, true)
+	}
+	if left == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%v: 401(k) deferrals at %v reach the 402(g) limit on %v, with %d paydays left in the year; the employer match on those is missed unless the plan trues it up",
+		p.Year, employer(p.Employer), time.Time(reached).Format("2006-01-02"), left)
+}
+
+// Check checks the paystubs of each year against the limits of the year, and
+// returns the warnings.  The amounts at the end of the year are projected
+// from the pay calendar, see Project.
+func Check(ts []tx.Transaction, o Options) []string {
+	years := map[int]bool{}
+	for _, t := range ts {
+		years[time.Time(t.Date).Year()] = true
+	}
+	for _, other := range o.Other {
+		years[other.Year] = true
+	}
+	var ys []int
+	for y := range years {
+		ys = append(ys, y)
+	}
+	sort.Ints(ys)
+	var r []string
+	for _, y := range ys {
+		l, err := For(y)
+		if err != nil {
+			r = append(r, fmt.Sprintf("%v: %v", y, err))
+			continue
+		}
+		r = append(r, check(Project(ts, y), o, y, l)...)
+	}
+	return r
+}
+
+func check(ps []Projection, o Options, year int, l Limits) []string {
+	var r []string
+	warn := func(w string) {
+		if w != "" {
+			r = append(r, w)
+		}
+	}
+	elective := l.Elective
+	annual := l.Annual
+	if o.CatchUp {
+		elective += l.CatchUp
+		annual += l.CatchUp
+	}
+	maxSS := l.MaxSocialSecurityTax()
+
+	// The totals of each employer, so far and at the end of the year.
+	type employerTotals struct {
+		name     string
+		ytd, end Totals
+	}
+	var es []employerTotals
+	for _, p := range ps {
+		es = append(es, employerTotals{p.Employer, p.YTD, p.YearEnd()})
+	}
+	for _, other := range o.Other {
+		if other.Year == year {
+			es = append(es, employerTotals{other.Employer, other.Totals, other.Totals})
+		}
+	}
+
+	// Payroll stops the elective deferrals at the 402(g) limit, so only the
+	// deferrals so far can be over it.
+	for i, e := range es {
+		es[i].end.Elective = capped(e.ytd.Elective, e.end.Elective, elective)
+	}
+	var ytd, end Totals
+	for _, e := range es {
+		ytd = ytd.add(e.ytd)
+		end = end.add(e.end)
+	}
+	warn(over(year, "401(k) elective deferrals", ytd.Elective, capped(ytd.Elective, end.Elective, elective), elective, "402(g)"))
+	for _, p := range ps {
+		warn(missedMatch(p, ytd.Elective-p.YTD.Elective, elective))
+	}
+	for _, e := range es {
+		warn(over(year, fmt.Sprintf("401(k) contributions at %v", employer(e.name)),
+			e.ytd.Elective+e.ytd.Match, e.end.Elective+e.end.Match, annual, "415(c)"))
+		warn(over(year, fmt.Sprintf("health FSA contributions at %v", employer(e.name)),
+			e.ytd.HealthFSA, e.end.HealthFSA, l.HealthFSA, "health FSA"))
+	}
+
+	// Each employer withholds the social security tax up to the wage base,
+	// so with several employers the total may be over the maximum.  The
+	// excess is a credit on Form 1040.  An employer that withholds more than
+	// the maximum has to refund it.
+	var ssYTD, ssEnd tx.USD
+	for _, e := range es {
+		if tx.Round(e.ytd.SocialSecurityTax) > maxSS {
+			warn(fmt.Sprintf("%v: %v withheld social security tax of %.2f, over the maximum of %.2f; ask the employer to refund %.2f",
+				year, employer(e.name), e.ytd.SocialSecurityTax, maxSS, e.ytd.SocialSecurityTax-maxSS))
+		}
+		ssYTD += e.ytd.SocialSecurityTax
+		ssEnd += capped(e.ytd.SocialSecurityTax, e.end.SocialSecurityTax, maxSS)
+	}
+	if len(es) > 1 {
+		switch {
+		case tx.Round(ssYTD) > maxSS:
+			warn(fmt.Sprintf("%v: social security tax of %.2f withheld by %d employers is over the maximum of %.2f; claim the excess %.2f on Form 1040",
+				year, ssYTD, len(es), maxSS, ssYTD-maxSS))
+		case tx.Round(ssEnd) > maxSS:
+			warn(fmt.Sprintf("%v: social security tax withheld by %d employers is projected to reach %.2f by the end of the year, over the maximum of %.2f; claim the excess %.2f on Form 1040",
+				year, len(es), ssEnd, maxSS, ssEnd-maxSS))
+		}
+	}
+
+	// Each employer withholds the additional Medicare tax only on its own
+	// wages over the threshold, which misses the wages over the threshold
+	// that are spread across employers.
+	if len(es) > 1 {
+		var withheldOn tx.USD
+		for _, e := range es {
+			withheldOn += tx.USD(math.Max(0, float64(e.end.MedicareWages-l.AdditionalMedicareThreshold)))
+		}
+		owedOn := tx.USD(math.Max(0, float64(end.MedicareWages-l.AdditionalMedicareThreshold)))
+		if missed := tx.Round(owedOn - withheldOn); missed > 0 {
+			warn(fmt.Sprintf("%v: Medicare wages of %.2f from %d employers are over the additional Medicare tax threshold of %.2f; the tax of %.2f on %.2f of them is not withheld",
+				year, end.MedicareWages, len(es), l.AdditionalMedicareThreshold, tx.Round(missed*AdditionalMedicareRate), missed))
+		}
+	}
+	return r
+}
@@ -0,0 +1,70 @@
+// Package limits contains the yearly IRS limits on the contributions to the
+// 401(k) and the health FSA, and on the wages subject to the payroll taxes,
+// and checks a year of paystubs against them.
+package limits
+
+import (
+	"github.com/filmil/fintools-public/pkg/tx"
+	"github.com/pkg/errors"
+)
+
+// Limits are the limits of one year.
+type Limits struct {
+	// Elective is the 402(g) limit on the employee's elective deferrals to
+	// the 401(k), across all employers.
+	Elective tx.USD
+	// CatchUp is the additional elective deferral allowed from the year the
+	// employee turns 50.
+	CatchUp tx.USD
+	// Annual is the 415(c) limit on the employee's and the employer's
+	// contributions to the 401(k) of one employer, without catch-up
+	// contributions.
+	Annual tx.USD
+	// HealthFSA is the limit on the employee's contributions to the health
+	// FSA of one employer.
+	HealthFSA tx.USD
+	// SocialSecurityWageBase is the maximum of the wages subject to the
+	// social security tax.
+	SocialSecurityWageBase tx.USD
+	// AdditionalMedicareThreshold is the amount of the Medicare wages above
+	// which the employer withholds the additional Medicare tax.
+	AdditionalMedicareThreshold tx.USD
+}
+
+// SocialSecurityRate is the employee's social security tax rate.
+const SocialSecurityRate = 0.062
+
+// AdditionalMedicareRate is the additional Medicare tax rate.
+const AdditionalMedicareRate = 0.009
+
+// table are the limits by year.  Add a year once the IRS and the SSA publish
+// its limits, usually in October or November of the year before.
+var table = map[int]Limits{
+	2015: {18000, 6000, 53000, 2550, 118500, 200000},
+	2016: {18000, 6000, 53000, 2550, 118500, 200000},
+	2017: {18000, 6000, 54000, 2600, 127200, 200000},
+	2018: {18500, 6000, 55000, 2650, 128400, 200000},
+	2019: {19000, 6000, 56000, 2700, 132900, 200000},
+	2020: {19500, 6500, 57000, 2750, 137700, 200000},
+	2021: {19500, 6500, 58000, 2750, 142800, 200000},
+	2022: {20500, 6500, 61000, 2850, 147000, 200000},
+	2023: {22500, 7500, 66000, 3050, 160200, 200000},
+	2024: {23000, 7500, 69000, 3200, 168600, 200000},
+	2025: {23500, 7500, 70000, 3300, 176100, 200000},
+	2026: {24500, 8000, 72000, 3400, 184500, 200000},
+}
+
+// For returns the limits of the year.
+func For(year int) (Limits, error) {
+	l, ok := table[year]
+	if !ok {
+		return Limits{}, errors.Errorf("no limits known for year %v", year)
+	}
+	return l, nil
+}
+
+// MaxSocialSecurityTax returns the social security tax on the wage base,
+// which is the most that the employee owes in a year.
+func (l Limits) MaxSocialSecurityTax() tx.USD {
+	return tx.Round(l.SocialSecurityWageBase * SocialSecurityRate)
+}
@@ -0,0 +1,184 @@
+package limits
+
+import (
+	"testing"
+	"time"
+
+	"github.com/filmil/fintools-public/pkg/tx"
+	"github.com/google/go-cmp/cmp"
+)
+
+func date(year int, month time.Month, day int) tx.DateOnly {
+	return tx.DateOnly(time.Date(year, month, day, 0, 0, 0, 0, time.UTC))
+}
+
+// paystub returns a regular paystub with the given 401(k) deferral and
+// employer match.
+func paystub(d tx.DateOnly, elective, match tx.USD) tx.Transaction {
+	t := tx.Transaction{
+		Date:                      d,
+		RegularPay:                10000,
+		Bonus401kPre:              elective,
+		SocialSecurityEmployeeTax: 620,
+		EmployeeMedicare:          145,
+	}
+	t.Employer.Bonus401kPre = match
+	return t
+}
+
+func TestPaydays(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name     string
+		rs       []tx.Transaction
+		expected []tx.DateOnly
+	}{
+		{
+			name: "biweekly",
+			rs: []tx.Transaction{
+				paystub(date(2020, 11, 20), 0, 0),
+				paystub(date(2020, 12, 4), 0, 0),
+			},
+			expected: []tx.DateOnly{date(2020, 12, 18)},
+		},
+		{
+			name: "semimonthly",
+			rs: []tx.Transaction{
+				paystub(date(2020, 10, 30), 0, 0),
+				paystub(date(2020, 11, 15), 0, 0),
+			},
+			expected: []tx.DateOnly{date(2020, 11, 30), date(2020, 12, 15), date(2020, 12, 30)},
+		},
+		{
+			name: "monthly",
+			rs: []tx.Transaction{
+				paystub(date(2020, 8, 31), 0, 0),
+				paystub(date(2020, 9, 30), 0, 0),
+			},
+			expected: []tx.DateOnly{date(2020, 10, 30), date(2020, 11, 30), date(2020, 12, 30)},
+		},
+		{
+			name: "from the pay period",
+			rs: []tx.Transaction{{
+				Date:           date(2020, 12, 4),
+				PayPeriodStart: date(2020, 11, 16),
+				PayPeriodEnd:   date(2020, 11, 29),
+			}},
+			expected: []tx.DateOnly{date(2020, 12, 18)},
+		},
+		{
+			name: "unknown calendar",
+			rs:   []tx.Transaction{paystub(date(2020, 12, 4), 0, 0)},
+		},
+	}
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			actual := Paydays(test.rs)
+			if diff := cmp.Diff(test.expected, actual, cmp.Comparer(tx.DateOnly.Equal)); diff != "" {
+				t.Errorf("Paydays(_)=%v, want: %v\ndiff:\n%v", actual, test.expected, diff)
+			}
+		})
+	}
+}
+
+// biweekly returns the regular paystubs of 2020 on every other Friday, from
+// Jan 10 until the given date.
+func biweekly(until tx.DateOnly, elective, match tx.USD) []tx.Transaction {
+	var ts []tx.Transaction
+	for d := date(2020, 1, 10); !time.Time(d).After(time.Time(until)); d = tx.DateOnly(time.Time(d).AddDate(0, 0, 14)) {
+		ts = append(ts, paystub(d, elective, match))
+	}
+	return ts
+}
+
+func TestCheck(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name     string
+		ts       []tx.Transaction
+		o        Options
+		expected []string
+	}{
+		{
+			name: "within the limits",
+			ts:   biweekly(date(2020, 6, 26), 500, 250),
+		},
+		{
+			name: "projected to the 402(g) limit",
+			// 13 paystubs so far and 13 more paydays at 800 each, which
+			// payroll stops at the limit.
+			ts: biweekly(date(2020, 6, 26), 800, 0),
+		},
+		{
+			name: "catch-up",
+			ts:   biweekly(date(2020, 6, 26), 800, 0),
+			o:    Options{CatchUp: true},
+		},
+		{
+			name: "over the 402(g) limit",
+			ts:   biweekly(date(2020, 12, 31), 800, 0),
+			expected: []string{
+				"2020: 401(k) elective deferrals of 20800.00 are over the 402(g) limit of 19500.00 by 1300.00",
+				"2020: the employer withheld social security tax of 16120.00, over the maximum of 8537.40; ask the employer to refund 7582.60",
+			},
+		},
+		{
+			name: "single year-end paystub over the 402(g) limit",
+			ts:   []tx.Transaction{paystub(date(2020, 12, 31), 20000, 0)},
+			expected: []string{
+				"2020: 401(k) elective deferrals of 20000.00 are over the 402(g) limit of 19500.00 by 500.00",
+			},
+		},
+		{
+			name: "missed match",
+			// The limit is reached on the 10th paystub, on May 15.
+			ts: biweekly(date(2020, 6, 26), 1950, 975),
+			expected: []string{
+				"2020: 401(k) elective deferrals of 25350.00 are over the 402(g) limit of 19500.00 by 5850.00",
+				"2020: 401(k) deferrals at the employer reach the 402(g) limit on 2020-05-15, with 16 paydays left in the year; the employer match on those is missed unless the plan trues it up",
+			},
+		},
+		{
+			name: "two employers",
+			ts:   biweekly(date(2020, 6, 26), 0, 0),
+			o: Options{Other: []Other{{
+				Year:     2020,
+				Employer: "12-3456789",
+				Totals: Totals{
+					SocialSecurityTax: 3100,
+					MedicareWages:     50000,
+				},
+			}}},
+			expected: []string{
+				"2020: social security tax of 11160.00 withheld by 2 employers is over the maximum of 8537.40; claim the excess 2622.60 on Form 1040",
+				"2020: Medicare wages of 310000.00 from 2 employers are over the additional Medicare tax threshold of 200000.00; the tax of 450.00 on 50000.00 of them is not withheld",
+			},
+		},
+		{
+			name: "over-withheld social security tax",
+			ts:   biweekly(date(2020, 12, 31), 0, 0),
+			expected: []string{
+				"2020: the employer withheld social security tax of 16120.00, over the maximum of 8537.40; ask the employer to refund 7582.60",
+			},
+		},
+		{
+			name: "unknown year",
+			ts:   []tx.Transaction{paystub(date(2099, 1, 10), 0, 0)},
+			expected: []string{
+				"2099: no limits known for year 2099",
+			},
+		},
+	}
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			actual := Check(test.ts, test.o)
+			if diff := cmp.Diff(test.expected, actual); diff != "" {
+				t.Errorf("Check(_, %+v)=%v, want: %v\ndiff:\n%v", test.o, actual, test.expected, diff)
+			}
+		})
+	}
+}
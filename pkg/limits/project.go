@@ -0,0 +1,176 @@
+package limits
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/filmil/fintools-public/pkg/tx"
+)
+
+// Totals are the amounts that count against the limits.
+type Totals struct {
+	// Elective are the employee's pre-tax 401(k) contributions.
+	Elective tx.USD `json:",omitempty"`
+	// Match are the employer's 401(k) contributions.
+	Match tx.USD `json:",omitempty"`
+	// HealthFSA are the employee's health FSA contributions.
+	HealthFSA         tx.USD `json:",omitempty"`
+	SocialSecurityTax tx.USD `json:",omitempty"`
+	MedicareWages     tx.USD `json:",omitempty"`
+}
+
+func (a Totals) add(b Totals) Totals {
+	return Totals{
+		Elective:          a.Elective + b.Elective,
+		Match:             a.Match + b.Match,
+		HealthFSA:         a.HealthFSA + b.HealthFSA,
+		SocialSecurityTax: a.SocialSecurityTax + b.SocialSecurityTax,
+		MedicareWages:     a.MedicareWages + b.MedicareWages,
+	}
+}
+
+func (a Totals) times(n int) Totals {
+	f := tx.USD(n)
+	return Totals{
+		Elective:          a.Elective * f,
+		Match:             a.Match * f,
+		HealthFSA:         a.HealthFSA * f,
+		SocialSecurityTax: a.SocialSecurityTax * f,
+		MedicareWages:     a.MedicareWages * f,
+	}
+}
+
+func totals(t tx.Transaction) Totals {
+	return Totals{
+		Elective:          t.Bonus401kPre,
+		Match:             t.Employer.Bonus401kPre,
+		HealthFSA:         t.FSAHealth,
+		SocialSecurityTax: t.SocialSecurityEmployeeTax,
+		MedicareWages:     t.Wages(tx.TaxFICA),
+	}
+}
+
+// regular returns true if t is paid on the pay calendar.
+func regular(t tx.Transaction) bool {
+	return t.Kind == "" && t.RegularPay != 0
+}
+
+// Projection are the totals of the paystubs of one employer in a year, and
+// what they are expected to be at the end of the year.
+type Projection struct {
+	Year int
+	// Employer is the company on the paystubs, which may be empty.
+	Employer string
+	// Paystubs are the paystubs of the year, in date order.
+	Paystubs []tx.Transaction
+	// YTD are the totals of the paystubs.
+	YTD Totals
+	// Paydays are the pay dates after the last regular paystub, until the
+	// end of the year.
+	Paydays []tx.DateOnly
+	// PerPayday are the amounts expected on each of the paydays, which are
+	// those of the last regular paystub.
+	PerPayday Totals
+}
+
+// YearEnd returns the totals expected at the end of the year, if the amounts
+// per payday do not change.
+func (p Projection) YearEnd() Totals {
+	return p.YTD.add(p.PerPayday.times(len(p.Paydays)))
+}
+
+// dayOf returns the date in the month with the given day of the month, or the
+// last day of the month if it is shorter.
+func dayOf(year int, month time.Month, day int) time.Time {
+	last := time.Date(year, month+1, 0, 0, 0, 0, 0, time.UTC).Day()
+	if day > last {
+		day = last
+	}
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+// Paydays returns the pay dates after the last of the regular paystubs rs,
+// until the end of the year.  The pay calendar follows from the last two
+// paystubs, or from the pay period of the last one if there is only one.
+// A weekly or biweekly calendar repeats every 7 or 14 days, and a monthly or
+// semimonthly one on the same days of each month.
+func Paydays(rs []tx.Transaction) []tx.DateOnly {
+	if len(rs) == 0 {
+		return nil
+	}
+	last := time.Time(rs[len(rs)-1].Date)
+	var days int
+	if len(rs) > 1 {
+		days = int(math.Round(last.Sub(time.Time(rs[len(rs)-2].Date)).Hours() / 24))
+	} else if p := rs[0]; !p.PayPeriodStart.IsZero() && !p.PayPeriodEnd.IsZero() {
+		days = int(math.Round(time.Time(p.PayPeriodEnd).Sub(time.Time(p.PayPeriodStart)).Hours()/24)) + 1
+	}
+	if days <= 0 {
+		return nil
+	}
+	var r []tx.DateOnly
+	var monthDays []int
+	switch {
+	case days >= 28 && days <= 31:
+		monthDays = []int{last.Day()}
+	case days >= 12 && days <= 18 && days != 14:
+		monthDays = []int{last.Day()}
+		if len(rs) > 1 {
+			monthDays = append(monthDays, time.Time(rs[len(rs)-2].Date).Day())
+		} else {
+			monthDays = append(monthDays, (last.Day()+15-1)%30+1)
+		}
+		sort.Ints(monthDays)
+	default:
+		for d := last.AddDate(0, 0, days); d.Year() == last.Year(); d = d.AddDate(0, 0, days) {
+			r = append(r, tx.DateOnly(d))
+		}
+		return r
+	}
+	for m := last.Month(); m <= time.December; m++ {
+		for _, day := range monthDays {
+			if d := dayOf(last.Year(), m, day); d.After(last) {
+				r = append(r, tx.DateOnly(d))
+			}
+		}
+	}
+	return r
+}
+
+// Project returns the projections of the paystubs of the year, one for each
+// employer, in the order in which the employers first appear.
+func Project(ts []tx.Transaction, year int) []Projection {
+	var ps []Projection
+	index := map[string]int{}
+	for _, t := range ts {
+		if time.Time(t.Date).Year() != year {
+			continue
+		}
+		i, ok := index[t.Company]
+		if !ok {
+			i = len(ps)
+			index[t.Company] = i
+			ps = append(ps, Projection{Year: year, Employer: t.Company})
+		}
+		ps[i].Paystubs = append(ps[i].Paystubs, t)
+	}
+	for i := range ps {
+		p := &ps[i]
+		sort.SliceStable(p.Paystubs, func(i, j int) bool {
+			return time.Time(p.Paystubs[i].Date).Before(time.Time(p.Paystubs[j].Date))
+		})
+		var rs []tx.Transaction
+		for _, t := range p.Paystubs {
+			p.YTD = p.YTD.add(totals(t))
+			if regular(t) {
+				rs = append(rs, t)
+			}
+		}
+		p.Paydays = Paydays(rs)
+		if len(rs) > 0 {
+			p.PerPayday = totals(rs[len(rs)-1])
+		}
+	}
+	return ps
+}
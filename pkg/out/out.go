@@ -3,7 +3,10 @@
 package out
 
 import (
+	"fmt"
 	"io"
+	"strconv"
+	"strings"
 	"text/template"
 	"time"
 
@@ -27,6 +30,7 @@ type Employer struct {
 	Vision         string
 	VolLifeEE      string
 	VolLifeSpouse  string
+	HSA            string
 }
 
 // Config contains the settings.
@@ -54,6 +58,7 @@ type Config struct {
 	Vision         string
 	VolLifeEE      string
 	VolLifeSpouse  string
+	HSA            string
 	// Taxes
 	FederalIncomeTax            string
 	EmployeeMedicare            string
@@ -62,14 +67,212 @@ type Config struct {
 	CAPrivateDisabilityEmployee string
 
 	Employer Employer
+
+	// Fallback accounts for the line items with unknown labels, used in
+	// lenient mode.
+	UnknownEarnings   string
+	UnknownDeductions string
+	UnknownTaxes      string
+	// UnknownTag is the tag added to transactions that have unknown items.
+	UnknownTag string
+
+	// VestAccount is the account that holds the vested shares.
+	VestAccount string
+	// VestClearing is the account through which the value of the deposited
+	// shares moves from the paystub transaction to the vest transaction.
+	VestClearing string
+	// VestSymbol is the commodity of the vested shares, if the vest does not
+	// name it.
+	VestSymbol string
+
+	// Imputed is how the imputed income is output, one of the Imputed*
+	// constants.  Empty means ImputedVisible.
+	Imputed string
+	// ImputedTag is the tag of the imputed income transactions.  Empty means
+	// DefaultImputedTag.
+	ImputedTag string
 }
 
+// DefaultImputedTag is the tag of the imputed income transactions if
+// Config.ImputedTag is not set.
+const DefaultImputedTag = "imputed"
+
+// Ways to output the imputed income, see tx.Transaction.Imputed.
+const (
+	// ImputedVisible books the imputed income and its offsetting deduction
+	// in a transaction of their own, tagged with Config.ImputedTag and linked
+	// to the paystub.  The reports of the taxable wages include it, and the
+	// cash flow reports can leave out the tag.
+	ImputedVisible = "visible"
+	// ImputedNet nets the imputed income out, and only notes it as metadata
+	// of the paystub transaction.
+	ImputedNet = "net"
+)
+
 // Out is the structure used to output transaction intormation.
 type Out struct {
 	T tx.Transaction
 	C Config
 }
 
+// Posting is a single beancount posting with optional metadata.
+type Posting struct {
+	Account string
+	Amount  tx.USD
+	// Label is the original paystub label, output as posting metadata.
+	Label string
+}
+
+// Unknowns returns the postings for the line items with unknown labels.
+// Employer items are skipped, same as the known employer items.
+func (o Out) Unknowns() []Posting {
+	var p []Posting
+	for _, u := range o.T.Unknown {
+		switch u.Section {
+		case tx.SectionEarnings:
+			p = append(p, Posting{o.C.UnknownEarnings, -u.Amount, u.Label})
+		case tx.SectionDeductions:
+			p = append(p, Posting{o.C.UnknownDeductions, u.Amount, u.Label})
+		case tx.SectionTaxes:
+			p = append(p, Posting{o.C.UnknownTaxes, u.Amount, u.Label})
+		}
+	}
+	return p
+}
+
+// Links returns the beancount links of the transaction.  An adjusting
+// transaction is linked to the one it adjusts even if that one is not known.
+// A transaction with vests or visible imputed income is linked to their
+// transactions.
+func (o Out) Links() []string {
+	l := o.T.Links
+	if len(l) == 0 && o.T.Adjusts != "" {
+		l = []string{tx.Link(o.T.Adjusts)}
+	}
+	if len(o.T.Vests) == 0 && o.VisibleImputed() == 0 {
+		return l
+	}
+	v := tx.Link(o.T.DocNum)
+	for _, s := range l {
+		if s == v {
+			return l
+		}
+	}
+	return append(append([]string(nil), l...), v)
+}
+
+// VisibleImputed returns the imputed income that is booked in a transaction
+// of its own, see ImputedVisible.
+func (o Out) VisibleImputed() tx.USD {
+	if o.C.Imputed == ImputedNet {
+		return 0
+	}
+	return o.T.Imputed()
+}
+
+// NetImputed returns the imputed income that is netted out, see ImputedNet.
+func (o Out) NetImputed() tx.USD {
+	if o.C.Imputed != ImputedNet {
+		return 0
+	}
+	return o.T.Imputed()
+}
+
+// ImputedTag returns the tag of the imputed income transaction.
+func (o Out) ImputedTag() string {
+	if o.C.ImputedTag == "" {
+		return DefaultImputedTag
+	}
+	return o.C.ImputedTag
+}
+
+// IGroupTermLife returns the group term life income that is not imputed.
+func (o Out) IGroupTermLife() tx.USD {
+	return o.T.IGroupTermLife - o.T.Imputed()
+}
+
+// EGroupTermLife returns the group term life deduction that does not offset
+// imputed income.
+func (o Out) EGroupTermLife() tx.USD {
+	return o.T.EGroupTermLife - o.T.Imputed()
+}
+
+// ClassCOffset returns the account of the Class C Offset deduction.  The
+// deduction takes the value of the deposited shares out of the paycheck, so
+// on a paystub with vests it goes to the vest clearing account.
+func (o Out) ClassCOffset() string {
+	if len(o.T.Vests) > 0 {
+		return o.C.VestClearing
+	}
+	return o.C.ClassCOffset
+}
+
+// VestClearing returns the value of the deposited shares that the paystub
+// transaction books to the vest clearing account, if the paystub has no
+// Class C Offset deduction that books it already.
+func (o Out) VestClearing() tx.USD {
+	if o.T.ClassCOffset != 0 {
+		return 0
+	}
+	var r tx.USD
+	for _, v := range o.T.Vests {
+		r += v.NetValue()
+	}
+	return r
+}
+
+// Vest is a stock vest, ready for output as its own beancount transaction.
+type Vest struct {
+	Date     tx.DateOnly
+	Symbol   string
+	Shares   float64
+	Withheld float64
+	FMV      tx.USD
+	// Net is the value of the deposited shares.
+	Net tx.USD
+}
+
+// Cost returns the beancount cost annotation of the shares.
+func (v Vest) Cost() string {
+	return fmt.Sprintf("{%v}", v.FMV)
+}
+
+func shares(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+func negShares(f float64) string {
+	return shares(-f)
+}
+
+// Vests returns the stock vests of the transaction.  A vest without a date
+// is dated on the pay date.
+func (o Out) Vests() []Vest {
+	var r []Vest
+	for _, v := range o.T.Vests {
+		ov := Vest{
+			Date:     v.Date,
+			Symbol:   v.Symbol,
+			Shares:   v.Shares,
+			Withheld: v.WithheldShares,
+			FMV:      v.FMV,
+			Net:      v.NetValue(),
+		}
+		if ov.Date.IsZero() {
+			ov.Date = o.T.Date
+		}
+		if ov.Symbol == "" {
+			ov.Symbol = o.C.VestSymbol
+		}
+		r = append(r, ov)
+	}
+	return r
+}
+
+func neg(v tx.USD) tx.USD {
+	return -v
+}
+
 // YMD formats time in the ISO YYYY-MM-DD format.
 func YMD(t tx.DateOnly) string {
 	tt := time.Time(t)
@@ -82,25 +285,55 @@ func year(t tx.DateOnly) string {
 }
 
 // The weird formatting is so that we can omit zero items without messing up
-// the output.
+// the output.  Income is negated with neg, so that negative income from
+// reversals and corrections comes out as a positive amount.
+//
+// The vested shares do not go through the paycheck.  The paystub transaction
+// books the value of the deposited shares to the vest clearing account, with
+// the Class C Offset deduction if the paystub has one, and each vest follows
+// as its own transaction, which takes it from there and acquires the shares
+// at the vest FMV.  The withheld shares are acquired and
+// disposed of at the same price, so that they are on record without a gain.
+//
+// The imputed income and its offsetting deduction do not go through the
+// paycheck either, so they are either in a tagged transaction of their own,
+// or netted out, see ImputedVisible and ImputedNet.
+//
+// The income tax postings note the supplemental wages and the tax withheld on
+// them as metadata, if the withholding was split, see withholding.Split.
 var outTpl = template.Must(template.New("tx").Funcs(
 	template.FuncMap{
-		"ymd":  YMD,
-		"year": year,
+		"ymd":       YMD,
+		"year":      year,
+		"neg":       neg,
+		"join":      strings.Join,
+		"shares":    shares,
+		"negShares": negShares,
 	},
-).Parse(`{{ymd .T.Date}} ! "GOOGLE LLC Payroll {{.T.DocNum}}"{{if .T.RegularPay}}
-   {{.C.RegularPay}} -{{.T.RegularPay}}{{end}}{{if .T.IGroupTermLife}}
-   {{.C.IGroupTermLife}} -{{.T.IGroupTermLife}}{{end}}{{if .T.AnnualBonus}}
-   {{.C.AnnualBonus}} -{{.T.AnnualBonus}}{{end}}{{if .T.PeerBonus}}
-   {{.C.PeerBonus}} -{{.T.PeerBonus}}{{end}}{{if .T.GoogStockUnit}}
-   {{.C.GoogStockUnit}} -{{.T.GoogStockUnit}}{{end}}{{if .T.SpotBonus}}
-   {{.C.SpotBonus}} -{{.T.SpotBonus}}{{end}}{{if .T.GSUCRefund}}
-   {{.C.GSUCRefund}} -{{.T.GSUCRefund}}{{end}}{{if .T.Bonus401kPre}}
+).Parse(`{{ymd .T.Date}} ! "GOOGLE LLC Payroll {{.T.DocNum}}"{{if .Unknowns}} #{{.C.UnknownTag}}{{end}}{{range .Links}} ^{{.}}{{end}}{{if .T.Kind}}
+   kind: {{printf "%q" .T.Kind}}{{end}}{{if .T.Adjusts}}
+   adjusts: {{printf "%q" .T.Adjusts}}{{end}}{{if .T.Missing}}
+   missing-sections: {{join .T.Missing ", " | printf "%q"}}{{end}}{{if not .T.PayPeriodStart.IsZero}}
+   pay-period-start: {{ymd .T.PayPeriodStart}}{{end}}{{if not .T.PayPeriodEnd.IsZero}}
+   pay-period-end: {{ymd .T.PayPeriodEnd}}{{end}}{{if not .T.CheckDate.IsZero}}
+   check-date: {{ymd .T.CheckDate}}{{end}}{{if .T.EmployeeID}}
+   employee-id: {{printf "%q" .T.EmployeeID}}{{end}}{{if .T.PayRate}}
+   pay-rate: {{.T.PayRate}}{{end}}{{if .T.Department}}
+   department: {{printf "%q" .T.Department}}{{end}}{{if .T.Company}}
+   company: {{printf "%q" .T.Company}}{{end}}{{if .NetImputed}}
+   imputed-income: {{.NetImputed}}{{end}}{{if .T.RegularPay}}
+   {{.C.RegularPay}} {{neg .T.RegularPay}}{{end}}{{if .IGroupTermLife}}
+   {{.C.IGroupTermLife}} {{neg .IGroupTermLife}}{{end}}{{if .T.AnnualBonus}}
+   {{.C.AnnualBonus}} {{neg .T.AnnualBonus}}{{end}}{{if .T.PeerBonus}}
+   {{.C.PeerBonus}} {{neg .T.PeerBonus}}{{end}}{{if .T.GoogStockUnit}}
+   {{.C.GoogStockUnit}} {{neg .T.GoogStockUnit}}{{end}}{{if .T.SpotBonus}}
+   {{.C.SpotBonus}} {{neg .T.SpotBonus}}{{end}}{{if .T.GSUCRefund}}
+   {{.C.GSUCRefund}} {{neg .T.GSUCRefund}}{{end}}{{if .T.Bonus401kPre}}
    {{.C.Bonus401kPre}} {{.T.Bonus401kPre}}{{end}}{{if .T.ClassCOffset}}
-   {{.C.ClassCOffset}} {{.T.ClassCOffset}}{{end}}{{if .T.Dental}}
+   {{.ClassCOffset}} {{.T.ClassCOffset}}{{end}}{{if .T.Dental}}
    {{.C.Dental}} {{.T.Dental}}{{end}}{{if .T.FSAHealth}}
-   {{.C.FSAHealth}} {{.T.FSAHealth}}{{end}}{{if .T.EGroupTermLife}}
-   {{.C.EGroupTermLife}} {{.T.EGroupTermLife}}{{end}}{{if .T.InternetReim}}
+   {{.C.FSAHealth}} {{.T.FSAHealth}}{{end}}{{if .EGroupTermLife}}
+   {{.C.EGroupTermLife}} {{.EGroupTermLife}}{{end}}{{if .T.InternetReim}}
    {{.C.InternetReim}} {{.T.InternetReim}}{{end}}{{if .T.LegalAccess}}
    {{.C.LegalAccess}} {{.T.LegalAccess}}{{end}}{{if .T.LongTermDis}}
    {{.C.LongTermDis}} {{.T.LongTermDis}}{{end}}{{if .T.Medical}}
@@ -108,14 +341,33 @@ var outTpl = template.Must(template.New("tx").Funcs(
    {{.C.TransitPreTax}} {{.T.TransitPreTax}}{{end}}{{if .T.Vision}}
    {{.C.Vision}} {{.T.Vision}}{{end}}{{if .T.VolLifeEE}}
    {{.C.VolLifeEE}} {{.T.VolLifeEE}}{{end}}{{if .T.VolLifeSpouse}}
-   {{.C.VolLifeSpouse}} {{.T.VolLifeSpouse}}{{end}}{{if .T.FederalIncomeTax}}
-   {{year .T.Date | printf .C.FederalIncomeTax}} {{.T.FederalIncomeTax}}{{end}}{{if .T.EmployeeMedicare}}
+   {{.C.VolLifeSpouse}} {{.T.VolLifeSpouse}}{{end}}{{if .T.HSA}}
+   {{.C.HSA}} {{.T.HSA}}{{end}}{{if .T.FederalIncomeTax}}
+   {{year .T.Date | printf .C.FederalIncomeTax}} {{.T.FederalIncomeTax}}{{with .T.Supplemental}}{{if .Wages}}
+     supplemental-wages: {{.Wages}}
+     supplemental-withheld: {{.FederalIncomeTax}}{{end}}{{end}}{{end}}{{if .T.EmployeeMedicare}}
    {{year .T.Date | printf .C.EmployeeMedicare}} {{.T.EmployeeMedicare}}{{end}}{{if .T.SocialSecurityEmployeeTax}}
    {{year .T.Date | printf .C.SocialSecurityEmployeeTax}} {{.T.SocialSecurityEmployeeTax}}{{end}}{{if .T.CAStateIncomeTax}}
-   {{year .T.Date | printf .C.CAStateIncomeTax}} {{.T.CAStateIncomeTax}}{{end}}{{if .T.CAPrivateDisabilityEmployee}}
-   {{year .T.Date | printf .C.CAPrivateDisabilityEmployee}} {{.T.CAPrivateDisabilityEmployee}}{{end}}{{if .T.NetPay}}
+   {{year .T.Date | printf .C.CAStateIncomeTax}} {{.T.CAStateIncomeTax}}{{with .T.Supplemental}}{{if .StateWages}}
+     supplemental-wages: {{.StateWages}}
+     supplemental-withheld: {{.CAStateIncomeTax}}{{end}}{{end}}{{end}}{{if .T.CAPrivateDisabilityEmployee}}
+   {{year .T.Date | printf .C.CAPrivateDisabilityEmployee}} {{.T.CAPrivateDisabilityEmployee}}{{end}}{{range .Unknowns}}
+   {{.Account}} {{.Amount}}
+     label: {{printf "%q" .Label}}{{end}}{{if .VestClearing}}
+   {{.C.VestClearing}} {{.VestClearing}}{{end}}{{if .T.NetPay}}
    {{.C.NetPay}} {{.T.NetPay}}{{end}}
-`))
+{{range .Vests}}
+{{ymd .Date}} * "GOOGLE LLC Vest {{.Symbol}} {{$.T.DocNum}}"{{range $.Links}} ^{{.}}{{end}}{{if $.T.GSUCRefund}}
+   refund: {{$.T.GSUCRefund}}{{end}}
+   {{$.C.VestAccount}} {{shares .Shares}} {{.Symbol}} {{.Cost}}{{if .Withheld}}
+   {{$.C.VestAccount}} {{negShares .Withheld}} {{.Symbol}} {{.Cost}} @ {{.FMV}}
+     withheld: TRUE{{end}}
+   {{$.C.VestClearing}} {{neg .Net}}
+{{end}}{{if .VisibleImputed}}
+{{ymd .T.Date}} * "GOOGLE LLC Imputed Income {{.T.DocNum}}" #{{.ImputedTag}}{{range .Links}} ^{{.}}{{end}}
+   {{.C.IGroupTermLife}} {{neg .VisibleImputed}}
+   {{.C.EGroupTermLife}} {{.VisibleImputed}}
+{{end}}`))
 
 func Output(t tx.Transaction, cfg Config, w io.Writer) error {
 	o := Out{T: t, C: cfg}
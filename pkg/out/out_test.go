@@ -0,0 +1,308 @@
+package out
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/filmil/fintools-public/pkg/tx"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestOutputUnknown(t *testing.T) {
+	t.Parallel()
+	tr := tx.Transaction{
+		Date:       tx.DateOnly(time.Date(2019, 1, 18, 0, 0, 0, 0, time.UTC)),
+		DocNum:     "42",
+		RegularPay: 100,
+		NetPay:     80,
+	}
+	tr.UnknownByName(tx.SectionEarnings, "Shiny Bonus", 5)
+	tr.UnknownByName(tx.SectionDeductions, "New Thing", 20)
+	tr.UnknownByName(tx.SectionDeductions, "New Thing", 5)
+	tr.UnknownByName(tx.SectionEmployer, "New Thing", 7)
+	cfg := Config{
+		RegularPay:        "Income:RegularPay",
+		NetPay:            "Assets:Checking",
+		UnknownEarnings:   "Income:Uncategorized",
+		UnknownDeductions: "Expenses:Uncategorized",
+		UnknownTag:        "paystub-unknown",
+	}
+	var b strings.Builder
+	if err := Output(tr, cfg, &b); err != nil {
+		t.Fatalf("Output: unexpected error: %v", err)
+	}
+	expected := `2019-01-18 ! "GOOGLE LLC Payroll 42" #paystub-unknown
+   Income:RegularPay -100.0000 USD
+   Income:Uncategorized -5.0000 USD
+     label: "Shiny Bonus"
+   Expenses:Uncategorized 25.0000 USD
+     label: "New Thing"
+   Assets:Checking 80.0000 USD
+`
+	if diff := cmp.Diff(expected, b.String()); diff != "" {
+		t.Errorf("Output(_)=\n%v\nwant:\n%v\ndiff:\n%v", b.String(), expected, diff)
+	}
+}
+
+func TestOutputHeader(t *testing.T) {
+	t.Parallel()
+	tr := tx.Transaction{
+		Date:           tx.DateOnly(time.Date(2019, 1, 18, 0, 0, 0, 0, time.UTC)),
+		DocNum:         "42",
+		PayPeriodStart: tx.DateOnly(time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)),
+		PayPeriodEnd:   tx.DateOnly(time.Date(2019, 1, 15, 0, 0, 0, 0, time.UTC)),
+		EmployeeID:     "123456",
+		PayRate:        150000,
+		Company:        "Google LLC",
+		RegularPay:     100,
+		NetPay:         100,
+	}
+	cfg := Config{
+		RegularPay: "Income:RegularPay",
+		NetPay:     "Assets:Checking",
+	}
+	var b strings.Builder
+	if err := Output(tr, cfg, &b); err != nil {
+		t.Fatalf("Output: unexpected error: %v", err)
+	}
+	expected := `2019-01-18 ! "GOOGLE LLC Payroll 42"
+   pay-period-start: 2019-01-01
+   pay-period-end: 2019-01-15
+   employee-id: "123456"
+   pay-rate: 150000.0000 USD
+   company: "Google LLC"
+   Income:RegularPay -100.0000 USD
+   Assets:Checking 100.0000 USD
+`
+	if diff := cmp.Diff(expected, b.String()); diff != "" {
+		t.Errorf("Output(_)=\n%v\nwant:\n%v\ndiff:\n%v", b.String(), expected, diff)
+	}
+}
+
+func TestOutputReversal(t *testing.T) {
+	t.Parallel()
+	tr := tx.Transaction{
+		Date:       tx.DateOnly(time.Date(2019, 1, 25, 0, 0, 0, 0, time.UTC)),
+		DocNum:     "43",
+		Kind:       tx.KindReversal,
+		Adjusts:    "42",
+		Missing:    []string{tx.SectionTaxes},
+		RegularPay: -100,
+		NetPay:     -100,
+	}
+	cfg := Config{
+		RegularPay: "Income:RegularPay",
+		NetPay:     "Assets:Checking",
+	}
+	var b strings.Builder
+	if err := Output(tr, cfg, &b); err != nil {
+		t.Fatalf("Output: unexpected error: %v", err)
+	}
+	expected := `2019-01-25 ! "GOOGLE LLC Payroll 43" ^paystub-42
+   kind: "reversal"
+   adjusts: "42"
+   missing-sections: "Taxes"
+   Income:RegularPay 100.0000 USD
+   Assets:Checking -100.0000 USD
+`
+	if diff := cmp.Diff(expected, b.String()); diff != "" {
+		t.Errorf("Output(_)=\n%v\nwant:\n%v\ndiff:\n%v", b.String(), expected, diff)
+	}
+}
+
+func TestOutputVest(t *testing.T) {
+	t.Parallel()
+	vest := tx.Vest{
+		Date:           tx.DateOnly(time.Date(2019, 1, 25, 0, 0, 0, 0, time.UTC)),
+		Shares:         10,
+		WithheldShares: 4,
+		FMV:            100,
+	}
+	cfg := Config{
+		NetPay:                    "Assets:Checking",
+		GoogStockUnit:             "Income:GoogStockUnit",
+		GSUCRefund:                "Income:GSUCRefund",
+		ClassCOffset:              "Expenses:ClassCOffset",
+		FederalIncomeTax:          "Expenses:Taxes:Y%s:Federal",
+		EmployeeMedicare:          "Expenses:Taxes:Y%s:Medicare",
+		SocialSecurityEmployeeTax: "Expenses:Taxes:Y%s:SocialSecurity",
+		CAStateIncomeTax:          "Expenses:Taxes:Y%s:CA",
+		VestAccount:               "Assets:Brokerage",
+		VestClearing:              "Assets:Clearing:GSU",
+		VestSymbol:                "GOOG",
+	}
+	// The withheld shares pay the taxes, the deposited ones are offset, and
+	// the refund of the excess withholding is the net pay.
+	stub := tx.Transaction{
+		Date:                      tx.DateOnly(time.Date(2019, 1, 31, 0, 0, 0, 0, time.UTC)),
+		DocNum:                    "42",
+		GoogStockUnit:             1000,
+		GSUCRefund:                20,
+		ClassCOffset:              600,
+		FederalIncomeTax:          220,
+		EmployeeMedicare:          14.5,
+		SocialSecurityEmployeeTax: 62,
+		CAStateIncomeTax:          103.5,
+		NetPay:                    20,
+		Vests:                     []tx.Vest{vest},
+	}
+	const vestTx = `
+2019-01-25 * "GOOGLE LLC Vest GOOG 42" ^paystub-42
+   refund: 20.0000 USD
+   Assets:Brokerage 10 GOOG {100.0000 USD}
+   Assets:Brokerage -4 GOOG {100.0000 USD} @ 100.0000 USD
+     withheld: TRUE
+   Assets:Clearing:GSU -600.0000 USD
+`
+	tests := []struct {
+		name     string
+		tr       tx.Transaction
+		expected string
+	}{
+		{
+			name: "offset on the paystub",
+			tr:   stub,
+			expected: `2019-01-31 ! "GOOGLE LLC Payroll 42" ^paystub-42
+   Income:GoogStockUnit -1000.0000 USD
+   Income:GSUCRefund -20.0000 USD
+   Assets:Clearing:GSU 600.0000 USD
+   Expenses:Taxes:Y2019:Federal 220.0000 USD
+   Expenses:Taxes:Y2019:Medicare 14.5000 USD
+   Expenses:Taxes:Y2019:SocialSecurity 62.0000 USD
+   Expenses:Taxes:Y2019:CA 103.5000 USD
+   Assets:Checking 20.0000 USD
+` + vestTx,
+		},
+		{
+			name: "no offset on the paystub",
+			tr: tx.Transaction{
+				Date:             stub.Date,
+				DocNum:           "42",
+				GoogStockUnit:    1000,
+				GSUCRefund:       20,
+				FederalIncomeTax: 400,
+				NetPay:           20,
+				Vests:            []tx.Vest{vest},
+			},
+			expected: `2019-01-31 ! "GOOGLE LLC Payroll 42" ^paystub-42
+   Income:GoogStockUnit -1000.0000 USD
+   Income:GSUCRefund -20.0000 USD
+   Expenses:Taxes:Y2019:Federal 400.0000 USD
+   Assets:Clearing:GSU 600.0000 USD
+   Assets:Checking 20.0000 USD
+` + vestTx,
+		},
+	}
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			var b strings.Builder
+			if err := Output(test.tr, cfg, &b); err != nil {
+				t.Fatalf("Output: unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(test.expected, b.String()); diff != "" {
+				t.Errorf("Output(_)=\n%v\nwant:\n%v\ndiff:\n%v", b.String(), test.expected, diff)
+			}
+		})
+	}
+}
+
+func TestOutputSupplemental(t *testing.T) {
+	t.Parallel()
+	tr := tx.Transaction{
+		Date:             tx.DateOnly(time.Date(2019, 3, 15, 0, 0, 0, 0, time.UTC)),
+		DocNum:           "42",
+		AnnualBonus:      1000,
+		FederalIncomeTax: 220,
+		CAStateIncomeTax: 102.3,
+		NetPay:           677.7,
+		Supplemental: &tx.Supplemental{
+			Wages:            1000,
+			StateWages:       1000,
+			FederalIncomeTax: 220,
+			CAStateIncomeTax: 102.3,
+		},
+	}
+	cfg := Config{
+		NetPay:           "Assets:Checking",
+		AnnualBonus:      "Income:AnnualBonus",
+		FederalIncomeTax: "Expenses:Taxes:Y%s:Federal",
+		CAStateIncomeTax: "Expenses:Taxes:Y%s:CA",
+	}
+	var b strings.Builder
+	if err := Output(tr, cfg, &b); err != nil {
+		t.Fatalf("Output: unexpected error: %v", err)
+	}
+	expected := `2019-03-15 ! "GOOGLE LLC Payroll 42"
+   Income:AnnualBonus -1000.0000 USD
+   Expenses:Taxes:Y2019:Federal 220.0000 USD
+     supplemental-wages: 1000.0000 USD
+     supplemental-withheld: 220.0000 USD
+   Expenses:Taxes:Y2019:CA 102.3000 USD
+     supplemental-wages: 1000.0000 USD
+     supplemental-withheld: 102.3000 USD
+   Assets:Checking 677.7000 USD
+`
+	if diff := cmp.Diff(expected, b.String()); diff != "" {
+		t.Errorf("Output(_)=\n%v\nwant:\n%v\ndiff:\n%v", b.String(), expected, diff)
+	}
+}
+
+func TestOutputImputed(t *testing.T) {
+	t.Parallel()
+	tr := tx.Transaction{
+		Date:           tx.DateOnly(time.Date(2019, 1, 31, 0, 0, 0, 0, time.UTC)),
+		DocNum:         "42",
+		RegularPay:     1000,
+		IGroupTermLife: 12,
+		EGroupTermLife: 15,
+		NetPay:         997,
+	}
+	cfg := Config{
+		NetPay:         "Assets:Checking",
+		RegularPay:     "Income:RegularPay",
+		IGroupTermLife: "Income:GroupTermLife",
+		EGroupTermLife: "Expenses:GroupTermLife",
+		// ImputedTag defaults to DefaultImputedTag.
+	}
+	tests := []struct {
+		imputed  string
+		expected string
+	}{
+		{
+			imputed: ImputedVisible,
+			// The deduction that is over the imputed income is paid.
+			expected: `2019-01-31 ! "GOOGLE LLC Payroll 42" ^paystub-42
+   Income:RegularPay -1000.0000 USD
+   Expenses:GroupTermLife 3.0000 USD
+   Assets:Checking 997.0000 USD
+
+2019-01-31 * "GOOGLE LLC Imputed Income 42" #imputed ^paystub-42
+   Income:GroupTermLife -12.0000 USD
+   Expenses:GroupTermLife 12.0000 USD
+`,
+		},
+		{
+			imputed: ImputedNet,
+			expected: `2019-01-31 ! "GOOGLE LLC Payroll 42"
+   imputed-income: 12.0000 USD
+   Income:RegularPay -1000.0000 USD
+   Expenses:GroupTermLife 3.0000 USD
+   Assets:Checking 997.0000 USD
+`,
+		},
+	}
+	for _, test := range tests {
+		c := cfg
+		c.Imputed = test.imputed
+		var b strings.Builder
+		if err := Output(tr, c, &b); err != nil {
+			t.Fatalf("Output: unexpected error: %v", err)
+		}
+		if diff := cmp.Diff(test.expected, b.String()); diff != "" {
+			t.Errorf("Output(_, %q)=\n%v\nwant:\n%v\ndiff:\n%v", test.imputed, b.String(), test.expected, diff)
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package summary
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/filmil/fintools-public/pkg/tx"
+)
+
+// Output formats.
+const (
+	FormatText = "text"
+	FormatCSV  = "csv"
+	FormatJSON = "json"
+)
+
+// sectionDerived is the section of the derived metrics in the tables.
+const sectionDerived = "Summary"
+
+// table returns the summaries as a table, with a row per line item and per
+// derived metric, and a column per period.
+func table(ss []Summary) [][]string {
+	var items []tx.Item
+	seen := map[tx.Item]bool{}
+	for _, s := range ss {
+		for _, i := range s.Items {
+			k := tx.Item{Section: i.Section, Label: i.Label}
+			if !seen[k] {
+				seen[k] = true
+				items = append(items, k)
+			}
+		}
+	}
+	sort.SliceStable(items, func(i, j int) bool {
+		a, b := items[i], items[j]
+		if a.Section != b.Section {
+			return tx.SectionIndex(a.Section) < tx.SectionIndex(b.Section)
+		}
+		return a.Label < b.Label
+	})
+
+	header := []string{"Section", "Label"}
+	for _, s := range ss {
+		header = append(header, s.Period)
+	}
+	r := [][]string{header}
+	amount := func(v tx.USD) string {
+		return fmt.Sprintf("%.2f", v)
+	}
+	percent := func(f float64) string {
+		return fmt.Sprintf("%.2f%%", 100*f)
+	}
+	for _, i := range items {
+		row := []string{i.Section, i.Label}
+		for _, s := range ss {
+			var v tx.USD
+			for _, si := range s.Items {
+				if si.Section == i.Section && si.Label == i.Label {
+					v = si.Amount
+				}
+			}
+			row = append(row, amount(v))
+		}
+		r = append(r, row)
+	}
+	for _, d := range []struct {
+		label string
+		f     func(Summary) string
+	}{
+		{"Paystubs", func(s Summary) string { return fmt.Sprint(s.Paystubs) }},
+		{"Net Pay", func(s Summary) string { return amount(s.NetPay) }},
+		{"Gross", func(s Summary) string { return amount(s.Gross) }},
+		{"Federal Wages", func(s Summary) string { return amount(s.FederalWages) }},
+		{"State Wages", func(s Summary) string { return amount(s.StateWages) }},
+		{"Federal Rate", func(s Summary) string { return percent(s.FederalRate) }},
+		{"State Rate", func(s Summary) string { return percent(s.StateRate) }},
+		{"401k Saved", func(s Summary) string { return amount(s.Saved401k) }},
+		{"Savings Rate", func(s Summary) string { return percent(s.SavingsRate) }},
+	} {
+		row := []string{sectionDerived, d.label}
+		for _, s := range ss {
+			row = append(row, d.f(s))
+		}
+		r = append(r, row)
+	}
+	return r
+}
+
+// Write writes the summaries in the format, one of the Format* constants.
+// The text and the CSV formats have a column per period.
+func Write(w io.Writer, ss []Summary, format string) error {
+	switch format {
+	case FormatText:
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		for _, row := range table(ss) {
+			fmt.Fprintf(tw, "%s\t\n", strings.Join(row, "\t"))
+		}
+		return tw.Flush()
+	case FormatCSV:
+		c := csv.NewWriter(w)
+		if err := c.WriteAll(table(ss)); err != nil {
+			return err
+		}
+		return c.Error()
+	case FormatJSON:
+		e := json.NewEncoder(w)
+		e.SetIndent("", "  ")
+		return e.Encode(ss)
+	}
+	return fmt.Errorf("unknown format %q, want %v, %v or %v", format, FormatText, FormatCSV, FormatJSON)
+}
@@ -0,0 +1,127 @@
+// Package summary aggregates paystub transactions into totals per period,
+// for year-end planning.
+package summary
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/filmil/fintools-public/pkg/tx"
+)
+
+// Periods that the transactions can be summarized by.
+const (
+	ByYear    = "year"
+	ByQuarter = "quarter"
+	ByMonth   = "month"
+)
+
+// Summary are the totals of the paystubs paid in a period.
+type Summary struct {
+	// Period is the period, like "2020", "2020-Q1" or "2020-03".
+	Period string
+	// Paystubs is the number of paystubs in the period.
+	Paystubs int
+	// Items are the totals of the line items per section and label, ordered
+	// like tx.Transaction.Items.  The employer contributions are in the
+	// "Employer" section.
+	Items []tx.Item
+
+	NetPay tx.USD
+	// Gross is the sum of all earnings.
+	Gross tx.USD
+	// FederalWages and StateWages are the wages subject to the federal
+	// and the state income tax.
+	FederalWages tx.USD
+	StateWages   tx.USD
+	// FederalRate and StateRate are the effective income tax rates, the
+	// income tax withheld divided by the taxable wages.
+	FederalRate float64
+	StateRate   float64
+	// Saved401k is the 401(k) contribution, of both the employee and the
+	// employer.
+	Saved401k tx.USD
+	// SavingsRate is Saved401k divided by Gross.
+	SavingsRate float64
+}
+
+// period returns the period of the date d.
+func period(d tx.DateOnly, by string) (string, error) {
+	t := time.Time(d)
+	switch by {
+	case ByYear:
+		return t.Format("2006"), nil
+	case ByQuarter:
+		return fmt.Sprintf("%d-Q%d", t.Year(), (int(t.Month())+2)/3), nil
+	case ByMonth:
+		return t.Format("2006-01"), nil
+	}
+	return "", fmt.Errorf("unknown period %q, want %v, %v or %v", by, ByYear, ByQuarter, ByMonth)
+}
+
+func ratio(a, b tx.USD) float64 {
+	if b == 0 {
+		return 0
+	}
+	return float64(a / b)
+}
+
+// Summarize returns the summaries of the transactions by period, in date
+// order.
+func Summarize(ts []tx.Transaction, by string) ([]Summary, error) {
+	byPeriod := map[string][]tx.Transaction{}
+	var ps []string
+	for _, t := range ts {
+		p, err := period(t.Date, by)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := byPeriod[p]; !ok {
+			ps = append(ps, p)
+		}
+		byPeriod[p] = append(byPeriod[p], t)
+	}
+	sort.Strings(ps)
+	var r []Summary
+	for _, p := range ps {
+		r = append(r, summarize(p, byPeriod[p]))
+	}
+	return r, nil
+}
+
+func summarize(p string, ts []tx.Transaction) Summary {
+	s := Summary{Period: p, Paystubs: len(ts)}
+	var federalTax, stateTax tx.USD
+	totals := map[tx.Item]tx.USD{}
+	for _, t := range ts {
+		s.NetPay += t.NetPay
+		s.Gross += t.Gross()
+		s.FederalWages += t.Wages(tx.TaxFederal)
+		s.StateWages += t.Wages(tx.TaxState)
+		federalTax += t.FederalIncomeTax
+		stateTax += t.CAStateIncomeTax
+		s.Saved401k += t.Bonus401kPre + t.Employer.Bonus401kPre
+		for _, i := range t.Items() {
+			k := tx.Item{Section: i.Section, Label: i.Label}
+			if _, ok := totals[k]; !ok {
+				s.Items = append(s.Items, k)
+			}
+			totals[k] += i.Amount
+		}
+	}
+	sort.SliceStable(s.Items, func(i, j int) bool {
+		a, b := s.Items[i], s.Items[j]
+		if a.Section != b.Section {
+			return tx.SectionIndex(a.Section) < tx.SectionIndex(b.Section)
+		}
+		return a.Label < b.Label
+	})
+	for i := range s.Items {
+		s.Items[i].Amount = totals[s.Items[i]]
+	}
+	s.FederalRate = ratio(federalTax, s.FederalWages)
+	s.StateRate = ratio(stateTax, s.StateWages)
+	s.SavingsRate = ratio(s.Saved401k, s.Gross)
+	return s
+}
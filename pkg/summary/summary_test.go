@@ -0,0 +1,57 @@
+package summary
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/filmil/fintools-public/pkg/tx"
+	"github.com/google/go-cmp/cmp"
+)
+
+func paystub(month time.Month, regular tx.USD) tx.Transaction {
+	return tx.Transaction{
+		Date:             tx.DateOnly(time.Date(2020, month, 15, 0, 0, 0, 0, time.UTC)),
+		NetPay:           regular - 300,
+		RegularPay:       regular,
+		Bonus401kPre:     100,
+		FederalIncomeTax: 180,
+		CAStateIncomeTax: 20,
+		Employer:         tx.Employer{Bonus401kPre: 50},
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	t.Parallel()
+	ts := []tx.Transaction{paystub(time.May, 1000), paystub(time.January, 1000), paystub(time.February, 2000)}
+	ss, err := Summarize(ts, ByQuarter)
+	if err != nil {
+		t.Fatalf("Summarize: unexpected error: %v", err)
+	}
+	var b strings.Builder
+	if err := Write(&b, ss, FormatCSV); err != nil {
+		t.Fatalf("Write: unexpected error: %v", err)
+	}
+	expected := `Section,Label,2020-Q1,2020-Q2
+Earnings,Regular Pay,3000.00,1000.00
+Deductions,Bonus 401K Pre,200.00,100.00
+Taxes,CA State Income Tax,40.00,20.00
+Taxes,Federal Income Tax,360.00,180.00
+Employer,Bonus 401K Pre,100.00,50.00
+Summary,Paystubs,2,1
+Summary,Net Pay,2400.00,700.00
+Summary,Gross,3000.00,1000.00
+Summary,Federal Wages,2800.00,900.00
+Summary,State Wages,2800.00,900.00
+Summary,Federal Rate,12.86%,20.00%
+Summary,State Rate,1.43%,2.22%
+Summary,401k Saved,300.00,150.00
+Summary,Savings Rate,10.00%,15.00%
+`
+	if diff := cmp.Diff(expected, b.String()); diff != "" {
+		t.Errorf("Write(_)=\n%v\nwant:\n%v\ndiff:\n%v", b.String(), expected, diff)
+	}
+	if _, err := Summarize(ts, "week"); err == nil {
+		t.Errorf("Summarize(_, %q): want error", "week")
+	}
+}
@@ -0,0 +1,181 @@
+package tx
+
+import (
+	"fmt"
+	"time"
+)
+
+// The kinds of paystubs, other than the regular ones.
+const (
+	// KindReversal reverses a prior paystub, such as a voided check.
+	KindReversal = "reversal"
+	// KindCorrection corrects the amounts of a prior paystub.
+	KindCorrection = "correction"
+	// KindOffCycle is a payment outside of the regular pay schedule.
+	KindOffCycle = "off-cycle"
+)
+
+// negate negates all amounts in the section.
+func (t *Transaction) negate(section string) {
+	for _, v := range t.fields(section) {
+		*v = -*v
+	}
+	for i, u := range t.Unknown {
+		if u.Section == section {
+			t.Unknown[i].Amount = -u.Amount
+		}
+	}
+}
+
+func (t *Transaction) negateVests() {
+	for i := range t.Vests {
+		t.Vests[i].Shares = -t.Vests[i].Shares
+		t.Vests[i].WithheldShares = -t.Vests[i].WithheldShares
+	}
+}
+
+// total returns the sum of the amounts in the section.
+func (t *Transaction) total(section string) USD {
+	var r USD
+	for _, v := range t.fields(section) {
+		r += *v
+	}
+	for _, u := range t.Unknown {
+		if u.Section == section {
+			r += u.Amount
+		}
+	}
+	return r
+}
+
+// Reverse makes a reversal take the money back.  Some paystubs print the
+// reversed amounts as they were, and some with their sign, and that may
+// differ from section to section.  Each section that adds up to more than
+// zero is negated, and so are a positive net pay and vests of a positive
+// number of shares.  A reversal of a stock vest, for one, has no net pay, but
+// still has to take the vest back.
+func (t *Transaction) Reverse() {
+	for _, s := range Sections {
+		if Round(t.total(s)) > 0 {
+			t.negate(s)
+		}
+	}
+	if t.NetPay > 0 {
+		t.NetPay = -t.NetPay
+	}
+	for _, v := range t.Vests {
+		if v.Shares > 0 {
+			t.negateVests()
+			break
+		}
+	}
+}
+
+// Subtract subtracts the amounts of o from the amounts of t.  The vests of t
+// that o has too, by their date and symbol, are subtracted as well, and
+// dropped if that leaves no shares.
+func (t *Transaction) Subtract(o Transaction) {
+	for _, s := range Sections {
+		of := o.fields(s)
+		for l, v := range t.fields(s) {
+			*v -= *of[l]
+		}
+	}
+	t.NetPay -= o.NetPay
+	for _, u := range o.Unknown {
+		found := false
+		for i, x := range t.Unknown {
+			if x.Section == u.Section && x.Label == u.Label {
+				t.Unknown[i].Amount -= u.Amount
+				found = true
+				break
+			}
+		}
+		if !found {
+			t.Unknown = append(t.Unknown, Unknown{Section: u.Section, Label: u.Label, Amount: -u.Amount})
+		}
+	}
+	var vs []Vest
+	for _, v := range t.Vests {
+		for _, ov := range o.Vests {
+			if v.Date.Equal(ov.Date) && v.Symbol == ov.Symbol {
+				v.Shares -= ov.Shares
+				v.WithheldShares -= ov.WithheldShares
+				break
+			}
+		}
+		if v.Shares != 0 || v.WithheldShares != 0 {
+			vs = append(vs, v)
+		}
+	}
+	t.Vests = vs
+	if t.Supplemental != nil && o.Supplemental != nil {
+		s, os := *t.Supplemental, *o.Supplemental
+		t.Supplemental = &Supplemental{
+			Wages:            s.Wages - os.Wages,
+			StateWages:       s.StateWages - os.StateWages,
+			FederalIncomeTax: s.FederalIncomeTax - os.FederalIncomeTax,
+			CAStateIncomeTax: s.CAStateIncomeTax - os.CAStateIncomeTax,
+		}
+	}
+}
+
+// Link returns the beancount link that ties an adjusting transaction to the
+// transaction with the document number docNum.
+func Link(docNum string) string {
+	return "paystub-" + docNum
+}
+
+// adjusts returns true if a transaction with Adjusts set to ref adjusts o.
+// The reference is either a document number, or a pay date in the
+// YYYY-MM-DD format.
+func adjusts(ref string, o Transaction) bool {
+	return ref == o.DocNum || ref == time.Time(o.Date).Format("2006-01-02")
+}
+
+// LinkAdjustments links the adjusting transactions in ts to the transactions
+// they adjust.  Both get the same link in Links, and Adjusts is set to the
+// document number of the adjusted transaction.  It returns a description of
+// each adjustment that does not match exactly one transaction in ts.
+//
+// A correction restates the full amounts of the paystub it corrects, so once
+// linked, the amounts of the original are subtracted from it: the correction
+// is booked as the change only, instead of counting the amounts twice.
+func LinkAdjustments(ts []Transaction) []string {
+	var r []string
+	for i := range ts {
+		t := &ts[i]
+		if t.Adjusts == "" {
+			continue
+		}
+		var found []int
+		for j, o := range ts {
+			if j != i && o.Adjusts == "" && adjusts(t.Adjusts, o) {
+				found = append(found, j)
+			}
+		}
+		if len(found) != 1 {
+			r = append(r, fmt.Sprintf("%v %v: %d paystubs match %q",
+				t.Kind, t.DocNum, len(found), t.Adjusts))
+			continue
+		}
+		o := &ts[found[0]]
+		if t.Kind == KindCorrection {
+			t.Subtract(*o)
+		}
+		t.Adjusts = o.DocNum
+		l := Link(o.DocNum)
+		t.Links = addLink(t.Links, l)
+		o.Links = addLink(o.Links, l)
+	}
+	return r
+}
+
+func addLink(ls []string, l string) []string {
+	for _, x := range ls {
+		if x == l {
+			return ls
+		}
+	}
+	return append(ls, l)
+}
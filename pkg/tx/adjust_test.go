@@ -0,0 +1,108 @@
+package tx
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestReverse(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name     string
+		tr       Transaction
+		expected Transaction
+	}{
+		{
+			name:     "printed as they were",
+			tr:       Transaction{RegularPay: 100, FederalIncomeTax: 10, NetPay: 90},
+			expected: Transaction{RegularPay: -100, FederalIncomeTax: -10, NetPay: -90},
+		},
+		{
+			name:     "printed with their sign",
+			tr:       Transaction{RegularPay: -100, FederalIncomeTax: -10, NetPay: -90},
+			expected: Transaction{RegularPay: -100, FederalIncomeTax: -10, NetPay: -90},
+		},
+		{
+			name: "stock vest without net pay",
+			tr: Transaction{
+				GoogStockUnit: 1000, FederalIncomeTax: 220, GSUCRefund: 780,
+				Vests: []Vest{{Shares: 10, WithheldShares: 3}},
+			},
+			expected: Transaction{
+				GoogStockUnit: -1000, FederalIncomeTax: -220, GSUCRefund: -780,
+				Vests: []Vest{{Shares: -10, WithheldShares: -3}},
+			},
+		},
+		{
+			name:     "mixed signs",
+			tr:       Transaction{RegularPay: 100, FederalIncomeTax: -10, NetPay: 0},
+			expected: Transaction{RegularPay: -100, FederalIncomeTax: -10, NetPay: 0},
+		},
+	}
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			actual := test.tr
+			actual.Reverse()
+			if diff := cmp.Diff(test.expected, actual, cmp.Comparer(DateOnly.Equal)); diff != "" {
+				t.Errorf("Reverse()=%+v, want: %+v\ndiff:\n%v", actual, test.expected, diff)
+			}
+		})
+	}
+}
+
+func TestLinkAdjustmentsCorrection(t *testing.T) {
+	t.Parallel()
+	original := Transaction{DocNum: "1", RegularPay: 1000, FederalIncomeTax: 100, NetPay: 900}
+	original.UnknownByName(SectionDeductions, "New Thing", 5)
+	correction := Transaction{
+		DocNum: "2", Kind: KindCorrection, Adjusts: "1",
+		RegularPay: 1100, FederalIncomeTax: 110, NetPay: 990,
+	}
+	ts := []Transaction{original, correction}
+	if warnings := LinkAdjustments(ts); len(warnings) != 0 {
+		t.Errorf("LinkAdjustments(_)=%v, want no warnings", warnings)
+	}
+	expected := Transaction{
+		DocNum: "2", Kind: KindCorrection, Adjusts: "1", Links: []string{"paystub-1"},
+		RegularPay: 100, FederalIncomeTax: 10, NetPay: 90,
+	}
+	expected.UnknownByName(SectionDeductions, "New Thing", -5)
+	if diff := cmp.Diff(expected, ts[1], cmp.Comparer(DateOnly.Equal)); diff != "" {
+		t.Errorf("LinkAdjustments(_) correction=%+v, want: %+v\ndiff:\n%v", ts[1], expected, diff)
+	}
+	if ts[0].RegularPay != 1000 {
+		t.Errorf("LinkAdjustments(_) changed the original: %+v", ts[0])
+	}
+}
+
+func TestLinkAdjustments(t *testing.T) {
+	t.Parallel()
+	ts := []Transaction{
+		{DocNum: "1", Date: date("2020-01-15")},
+		{DocNum: "2", Date: date("2020-01-31")},
+		{DocNum: "3", Kind: KindReversal, Adjusts: "1"},
+		{DocNum: "4", Kind: KindCorrection, Adjusts: "2020-01-31"},
+		{DocNum: "5", Kind: KindCorrection, Adjusts: "999"},
+	}
+	warnings := LinkAdjustments(ts)
+	if len(warnings) != 1 {
+		t.Errorf("LinkAdjustments(_)=%v, want one warning", warnings)
+	}
+	var links [][]string
+	var adjusts []string
+	for _, tr := range ts {
+		links = append(links, tr.Links)
+		adjusts = append(adjusts, tr.Adjusts)
+	}
+	expectedLinks := [][]string{{"paystub-1"}, {"paystub-2"}, {"paystub-1"}, {"paystub-2"}, nil}
+	if diff := cmp.Diff(expectedLinks, links); diff != "" {
+		t.Errorf("LinkAdjustments(_) links=%v, want: %v\ndiff:\n%v", links, expectedLinks, diff)
+	}
+	expectedAdjusts := []string{"", "", "1", "2", "999"}
+	if diff := cmp.Diff(expectedAdjusts, adjusts); diff != "" {
+		t.Errorf("LinkAdjustments(_) adjusts=%v, want: %v\ndiff:\n%v", adjusts, expectedAdjusts, diff)
+	}
+}
@@ -0,0 +1,43 @@
+package tx
+
+import (
+	"sort"
+	"time"
+)
+
+// Gap is a stretch of days that no pay period covers.
+type Gap struct {
+	// Start and End are the first and the last day of the gap.
+	Start, End DateOnly
+}
+
+// Gaps returns the gaps between the pay periods of the transactions, in
+// order.  Transactions without a pay period are skipped.  Overlapping pay
+// periods, such as those of off-cycle payments, are not gaps.
+func Gaps(ts []Transaction) []Gap {
+	var ps []Transaction
+	for _, t := range ts {
+		if t.PayPeriodStart.IsZero() || t.PayPeriodEnd.IsZero() {
+			continue
+		}
+		ps = append(ps, t)
+	}
+	sort.SliceStable(ps, func(i, j int) bool {
+		return time.Time(ps[i].PayPeriodStart).Before(time.Time(ps[j].PayPeriodStart))
+	})
+	var gs []Gap
+	var covered time.Time
+	for i, t := range ps {
+		start, end := time.Time(t.PayPeriodStart), time.Time(t.PayPeriodEnd)
+		if i > 0 {
+			next := covered.AddDate(0, 0, 1)
+			if start.After(next) {
+				gs = append(gs, Gap{Start: DateOnly(next), End: DateOnly(start.AddDate(0, 0, -1))})
+			}
+		}
+		if end.After(covered) {
+			covered = end
+		}
+	}
+	return gs
+}
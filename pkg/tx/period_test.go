@@ -0,0 +1,54 @@
+package tx
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func date(s string) DateOnly {
+	d, err := time.Parse("2006-01-02", s)
+	if err != nil {
+		panic(err)
+	}
+	return DateOnly(d)
+}
+
+func period(start, end string) Transaction {
+	return Transaction{PayPeriodStart: date(start), PayPeriodEnd: date(end)}
+}
+
+func TestGaps(t *testing.T) {
+	t.Parallel()
+	ts := []Transaction{
+		period("2020-02-01", "2020-02-15"),
+		period("2020-01-01", "2020-01-15"),
+		period("2020-01-16", "2020-01-31"),
+		// Off-cycle payment within a pay period.
+		period("2020-01-16", "2020-01-20"),
+		// No pay period.
+		{},
+		period("2020-03-01", "2020-03-15"),
+	}
+	expected := []Gap{{Start: date("2020-02-16"), End: date("2020-02-29")}}
+	actual := Gaps(ts)
+	if diff := cmp.Diff(expected, actual, cmp.Comparer(DateOnly.Equal)); diff != "" {
+		t.Errorf("Gaps(_)=%v, want: %v\ndiff:\n%v", actual, expected, diff)
+	}
+}
+
+func TestDateOnlyJSON(t *testing.T) {
+	t.Parallel()
+	b, err := date("2020-01-02").MarshalJSON()
+	if err != nil {
+		t.Fatalf("MarshalJSON: unexpected error: %v", err)
+	}
+	var d DateOnly
+	if err := d.UnmarshalJSON(b); err != nil {
+		t.Fatalf("UnmarshalJSON(%s): unexpected error: %v", b, err)
+	}
+	if !d.Equal(date("2020-01-02")) {
+		t.Errorf("round trip of %s=%v", b, time.Time(d))
+	}
+}
@@ -2,10 +2,12 @@
 package tx
 
 import (
-	"fmt"
-	"time"
 	"encoding/json"
+	"fmt"
 	"io"
+	"math"
+	"sort"
+	"time"
 
 	"github.com/golang/glog"
 )
@@ -27,6 +29,7 @@ type Employer struct {
 	Vision         USD `json:",omitempty"`
 	VolLifeEE      USD `json:",omitempty"`
 	VolLifeSpouse  USD `json:",omitempty"`
+	HSA            USD `json:",omitempty"`
 }
 
 type DateOnly time.Time
@@ -37,6 +40,15 @@ func (d DateOnly) Equal(o DateOnly) bool {
 	return t1.Equal(t2)
 }
 
+// IsZero returns true if the date is not set.
+func (d DateOnly) IsZero() bool {
+	return time.Time(d).IsZero()
+}
+
+func (d DateOnly) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Time(d).Format("2006-01-02"))
+}
+
 func (d *DateOnly) UnmarshalJSON(b []byte) error {
 	var s string
 	if err := json.Unmarshal(b, &s); err != nil {
@@ -57,6 +69,39 @@ type Transaction struct {
 	// DocNum is the document number (if any)
 	DocNum string `json:",omitempty"`
 
+	// Header fields.  Not all paystubs have them, so they are zero if
+	// missing.
+	PayPeriodStart DateOnly `json:",omitempty"`
+	PayPeriodEnd   DateOnly `json:",omitempty"`
+	// CheckDate is the date on the check, if different from Date.
+	CheckDate  DateOnly `json:",omitempty"`
+	EmployeeID string   `json:",omitempty"`
+	// PayRate is the pay rate or the salary, as printed.  The paystub may
+	// give it per hour or per year.
+	PayRate    USD    `json:",omitempty"`
+	Department string `json:",omitempty"`
+	Company    string `json:",omitempty"`
+	// W4 are the withholding settings, if the paystub lists them.
+	W4 W4 `json:",omitempty"`
+
+	// Kind is the kind of the paystub, one of the Kind* constants, or empty
+	// for a regular paystub.
+	Kind string `json:",omitempty"`
+	// Adjusts refers to the paystub that this one reverses or corrects, by
+	// its document number or by its pay date as YYYY-MM-DD.
+	Adjusts string `json:",omitempty"`
+	// Links are the beancount links of the transaction, see LinkAdjustments.
+	Links []string `json:",omitempty"`
+	// Missing are the sections that the paystub does not have, such as
+	// "Deductions" on a bonus-only paystub.
+	Missing []string `json:",omitempty"`
+	// Vests are the stock unit vests paid out by the paystub, see Vest.
+	Vests []Vest `json:",omitempty"`
+	// Supplemental is the part of the income tax withholding that is on the
+	// supplemental wages, if the paystub has them and its withholding could
+	// be split, see withholding.Split.
+	Supplemental *Supplemental `json:",omitempty"`
+
 	NetPay         USD `json:",omitempty"`
 	RegularPay     USD `json:",omitempty"`
 	AnnualBonus    USD `json:",omitempty"`
@@ -80,6 +125,7 @@ type Transaction struct {
 	Vision         USD `json:",omitempty"`
 	VolLifeEE      USD `json:",omitempty"`
 	VolLifeSpouse  USD `json:",omitempty"`
+	HSA            USD `json:",omitempty"`
 
 	// Taxes
 	FederalIncomeTax            USD `json:",omitempty"`
@@ -88,8 +134,28 @@ type Transaction struct {
 	CAStateIncomeTax            USD `json:",omitempty"`
 	CAPrivateDisabilityEmployee USD `json:",omitempty"`
 
-
 	Employer Employer `json:",omitempty"`
+
+	// Unknown are the line items whose labels could not be mapped to any of
+	// the fields above.  Only filled in lenient mode.
+	Unknown []Unknown `json:",omitempty"`
+}
+
+// Paystub sections in which an Unknown line item may be found.
+const (
+	SectionEarnings   = "Earnings"
+	SectionDeductions = "Deductions"
+	SectionTaxes      = "Taxes"
+	SectionEmployer   = "Employer"
+)
+
+// Unknown is a paystub line item with a label that has no known mapping.
+type Unknown struct {
+	// Section is the paystub section the item was found in, e.g. "Earnings".
+	Section string
+	// Label is the original label text from the paystub.
+	Label  string
+	Amount USD
 }
 
 // Read gets a Transaction from a Reader.
@@ -103,113 +169,198 @@ func Read(r io.Reader) (Transaction, error) {
 	return tx, nil
 }
 
+// incomeFields maps the earnings labels found on a paystub to the fields of t
+// that account for them.
+func (t *Transaction) incomeFields() map[string]*USD {
+	return map[string]*USD{
+		"Annual Bonus":    &t.AnnualBonus,
+		"Group Term Life": &t.IGroupTermLife,
+		"Peer Bonus":      &t.PeerBonus,
+		"Regular Pay":     &t.RegularPay,
+		"Goog Stock Unit": &t.GoogStockUnit,
+		"Spot Bonus":      &t.SpotBonus,
+	}
+}
+
+// deductionFields maps the deduction labels found on a paystub to the fields
+// of t that account for them.
+func (t *Transaction) deductionFields() map[string]*USD {
+	return map[string]*USD{
+		"Bonus 401K Pre":  &t.Bonus401kPre,
+		"Class C Offset":  &t.ClassCOffset,
+		"GSU C Refund":    &t.GSUCRefund,
+		"Dental":          &t.Dental,
+		"FSA Health":      &t.FSAHealth,
+		"Group Term Life": &t.EGroupTermLife,
+		"Internet Reim":   &t.InternetReim,
+		"LegalAccess":     &t.LegalAccess,
+		"LongTerm Dis":    &t.LongTermDis,
+		"Medical":         &t.Medical,
+		"Transit PreTax":  &t.TransitPreTax,
+		"Vision":          &t.Vision,
+		"Vol Life EE":     &t.VolLifeEE,
+		"Vol Life Spouse": &t.VolLifeSpouse,
+		"HSA":             &t.HSA,
+	}
+}
+
+// taxFields maps the tax labels found on a paystub to the fields of t that
+// account for them.
+func (t *Transaction) taxFields() map[string]*USD {
+	return map[string]*USD{
+		"Federal Income Tax":             &t.FederalIncomeTax,
+		"Employee Medicare":              &t.EmployeeMedicare,
+		"Social Security Employee Tax":   &t.SocialSecurityEmployeeTax,
+		"CA State Income Tax":            &t.CAStateIncomeTax,
+		"CA Private Disability Employee": &t.CAPrivateDisabilityEmployee,
+	}
+}
+
+// employerFields maps the deduction labels found on a paystub to the fields of
+// t that account for the employer's part of them.
+func (t *Transaction) employerFields() map[string]*USD {
+	e := &t.Employer
+	return map[string]*USD{
+		"Bonus 401K Pre":  &e.Bonus401kPre,
+		"Class C Offset":  &e.ClassCOffset,
+		"GSU C Refund":    &e.GSUCRefund,
+		"Dental":          &e.Dental,
+		"FSA Health":      &e.FSAHealth,
+		"Group Term Life": &e.EGroupTermLife,
+		"Internet Reim":   &e.InternetReim,
+		"LegalAccess":     &e.LegalAccess,
+		"LongTerm Dis":    &e.LongTermDis,
+		"Medical":         &e.Medical,
+		"Transit PreTax":  &e.TransitPreTax,
+		"Vision":          &e.Vision,
+		"Vol Life EE":     &e.VolLifeEE,
+		"Vol Life Spouse": &e.VolLifeSpouse,
+		"HSA":             &e.HSA,
+	}
+}
+
+// fields returns the label to field mapping for the given paystub section.
+func (t *Transaction) fields(section string) map[string]*USD {
+	switch section {
+	case SectionEarnings:
+		return t.incomeFields()
+	case SectionDeductions:
+		return t.deductionFields()
+	case SectionTaxes:
+		return t.taxFields()
+	case SectionEmployer:
+		return t.employerFields()
+	}
+	return nil
+}
+
+// Sections are all the paystub sections, in the order they are output.
+var Sections = []string{SectionEarnings, SectionDeductions, SectionTaxes, SectionEmployer}
+
+// SectionIndex returns the index of the section in Sections.  Sections that
+// are not paystub sections sort after them.
+func SectionIndex(section string) int {
+	for i, s := range Sections {
+		if s == section {
+			return i
+		}
+	}
+	return len(Sections)
+}
+
+// Labels returns the known labels of the given paystub section, sorted.
+func Labels(section string) []string {
+	var l []string
+	for k := range (&Transaction{}).fields(section) {
+		l = append(l, k)
+	}
+	sort.Strings(l)
+	return l
+}
+
+// Item is a single line item of a paystub.
+type Item struct {
+	// Section is the paystub section of the item, e.g. "Earnings".
+	Section string
+	// Label is the label of the item as it appears on the paystub.
+	Label  string
+	Amount USD
+}
+
+// Items returns all nonzero line items of t, including the unknown ones,
+// ordered by section and then by label.
+func (t Transaction) Items() []Item {
+	var r []Item
+	for _, s := range Sections {
+		f := t.fields(s)
+		for _, l := range Labels(s) {
+			if v := *f[l]; v != 0 {
+				r = append(r, Item{Section: s, Label: l, Amount: v})
+			}
+		}
+		for _, u := range t.Unknown {
+			if u.Section == s && u.Amount != 0 {
+				r = append(r, Item(u))
+			}
+		}
+	}
+	return r
+}
+
 func (t *Transaction) IncomeByName(s string, amount USD) error {
-	switch s {
-	case "Annual Bonus":
-		t.AnnualBonus += amount
-	case "Group Term Life":
-		t.IGroupTermLife += amount
-	case "Peer Bonus":
-		t.PeerBonus += amount
-	case "Regular Pay":
-		t.RegularPay += amount
-	case "Goog Stock Unit":
-		t.GoogStockUnit += amount
-	case "Spot Bonus":
-		t.SpotBonus += amount
-	default:
+	f, ok := t.incomeFields()[s]
+	if !ok {
 		return fmt.Errorf("Income by name not found: %q to place amount %v", s, amount)
 	}
+	*f += amount
 	return nil
 }
 
+// ExpenseByName accounts for either a deduction or a tax.
 func (t *Transaction) ExpenseByName(s string, amount USD) error {
 	glog.V(3).Infof("ExpenseByname: expense: %q, amount: %v", s, amount)
-	switch s {
-	case "Bonus 401K Pre":
-		t.Bonus401kPre += amount
-	case "Class C Offset":
-		t.ClassCOffset += amount
-	case "GSU C Refund":
-		t.GSUCRefund += amount
-	case "Dental":
-		t.Dental += amount
-	case "FSA Health":
-		t.FSAHealth += amount
-	case "Group Term Life":
-		t.EGroupTermLife += amount
-	case "Internet Reim":
-		t.InternetReim += amount
-	case "LegalAccess":
-		t.LegalAccess += amount
-	case "LongTerm Dis":
-		t.LongTermDis += amount
-	case "Medical":
-		t.Medical += amount
-	case "Transit PreTax":
-		t.TransitPreTax += amount
-	case "Vision":
-		t.Vision += amount
-	case "Vol Life EE":
-		t.VolLifeEE += amount
-	case "Vol Life Spouse":
-		t.VolLifeSpouse += amount
-	case "Federal Income Tax":
-		t.FederalIncomeTax += amount
-	case "Employee Medicare":
-		t.EmployeeMedicare += amount
-	case "Social Security Employee Tax":
-		t.SocialSecurityEmployeeTax += amount
-	case "CA State Income Tax":
-		t.CAStateIncomeTax += amount
-	case "CA Private Disability Employee":
-		t.CAPrivateDisabilityEmployee += amount
-	default:
+	f, ok := t.deductionFields()[s]
+	if !ok {
+		f, ok = t.taxFields()[s]
+	}
+	if !ok {
 		return fmt.Errorf("ExpenseByName: not found: %q to place amount %v", s, amount)
 	}
+	*f += amount
 	return nil
 }
 
 func (t *Transaction) EmployerExpenseByName(s string, amount USD) error {
 	glog.V(3).Infof("EmployerExpenseByName: expense: %q, amount: %v", s, amount)
-	switch s {
-	case "Bonus 401K Pre":
-		t.Employer.Bonus401kPre += amount
-	case "Class C Offset":
-		t.Employer.ClassCOffset += amount
-	case "GSU C Refund":
-		t.Employer.GSUCRefund += amount
-	case "Dental":
-		t.Employer.Dental += amount
-	case "FSA Health":
-		t.Employer.FSAHealth += amount
-	case "Group Term Life":
-		t.Employer.EGroupTermLife += amount
-	case "Internet Reim":
-		t.Employer.InternetReim += amount
-	case "LegalAccess":
-		t.Employer.LegalAccess += amount
-	case "LongTerm Dis":
-		t.Employer.LongTermDis += amount
-	case "Medical":
-		t.Employer.Medical += amount
-	case "Transit PreTax":
-		t.Employer.TransitPreTax += amount
-	case "Vision":
-		t.Employer.Vision += amount
-	case "Vol Life EE":
-		t.Employer.VolLifeEE += amount
-	case "Vol Life Spouse":
-		t.Employer.VolLifeSpouse += amount
-	default:
+	f, ok := t.employerFields()[s]
+	if !ok {
 		return fmt.Errorf("EmployerExpenseByName: not found: %q to place amount %v", s, amount)
 	}
+	*f += amount
 	return nil
 }
 
+// UnknownByName records an amount whose label s was not recognized in the
+// given paystub section.  Amounts with the same section and label are added up.
+func (t *Transaction) UnknownByName(section, s string, amount USD) {
+	glog.V(3).Infof("UnknownByName: section: %q, label: %q, amount: %v", section, s, amount)
+	for i, u := range t.Unknown {
+		if u.Section == section && u.Label == s {
+			t.Unknown[i].Amount += amount
+			return
+		}
+	}
+	t.Unknown = append(t.Unknown, Unknown{Section: section, Label: s, Amount: amount})
+}
+
 // USD is the currency in this paystub.
 type USD float64
 
+// Round returns v rounded to cents.
+func Round(v USD) USD {
+	return USD(math.Round(float64(v)*100) / 100)
+}
+
 func (v USD) String() string {
 	return fmt.Sprintf("%.4f USD", v)
 }
@@ -0,0 +1,121 @@
+package tx
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"time"
+)
+
+// Vest is a vest of stock units, such as GSUs, paid out on a paystub.
+type Vest struct {
+	// Date is the vest date.
+	Date DateOnly `json:",omitempty"`
+	// Symbol is the stock symbol, e.g. "GOOG".
+	Symbol string `json:",omitempty"`
+	// Shares is the number of vested shares, including the withheld ones.
+	Shares float64 `json:",omitempty"`
+	// WithheldShares is the number of shares withheld to cover the taxes.
+	WithheldShares float64 `json:",omitempty"`
+	// FMV is the fair market value of one share at vest, which is the cost
+	// basis of the shares.
+	FMV USD `json:",omitempty"`
+}
+
+// NetShares returns the number of shares that were deposited.
+func (v Vest) NetShares() float64 {
+	return v.Shares - v.WithheldShares
+}
+
+// NetValue returns the value of the deposited shares at the vest FMV.
+func (v Vest) NetValue() USD {
+	return Round(USD(v.NetShares()) * v.FMV)
+}
+
+// ReadVests reads vests from a JSON list of vest confirmations, such as:
+//
+//	[{"Date": "2020-01-25", "Symbol": "GOOG", "Shares": 10, "WithheldShares": 4, "FMV": 1450.5}]
+func ReadVests(r io.Reader) ([]Vest, error) {
+	var vs []Vest
+	if err := json.NewDecoder(r).Decode(&vs); err != nil {
+		return nil, fmt.Errorf("could not read vests: %v", err)
+	}
+	return vs, nil
+}
+
+// maxVestDelay is the longest time from a vest to the paystub that pays it
+// out.
+const maxVestDelay = 45 * 24 * time.Hour
+
+// Value returns the value of all vested shares at the vest FMV, which is the
+// stock unit income on the paystub.
+func (v Vest) Value() USD {
+	return Round(USD(v.Shares) * v.FMV)
+}
+
+// tolerance returns how far the stock unit income may be off the value of the
+// vests of the shares, due to the rounding of the FMV to cents.
+func tolerance(shares float64) USD {
+	return USD(0.01 + math.Abs(shares)*0.005)
+}
+
+// shares returns the number of the shares vested in vs.
+func shares(vs []Vest) float64 {
+	var r float64
+	for _, v := range vs {
+		r += v.Shares
+	}
+	return r
+}
+
+// MatchVests adds the vests to the transactions that pay them out: the
+// earliest paystub with stock unit income that does not list its own vests,
+// paid on or shortly after the vest date, and with enough stock unit income
+// left for the value of the vest.  It returns a description of each vest that
+// matches no transaction, and of each transaction whose stock unit income is
+// not the value of the vests it got, within rounding.
+func MatchVests(ts []Transaction, vs []Vest) []string {
+	var r []string
+	// matched are the transactions that got vests here, as opposed to
+	// listing them on the paystub, and the value of those vests.
+	matched := map[int]USD{}
+	for _, v := range vs {
+		vd := time.Time(v.Date)
+		best := -1
+		for i, t := range ts {
+			d := time.Time(t.Date)
+			if t.GoogStockUnit == 0 || d.Before(vd) || d.Sub(vd) > maxVestDelay {
+				continue
+			}
+			value, ok := matched[i]
+			if len(t.Vests) > 0 && !ok {
+				continue
+			}
+			if v.Value()+value > t.GoogStockUnit+tolerance(shares(t.Vests)+v.Shares) {
+				continue
+			}
+			if best < 0 || d.Before(time.Time(ts[best].Date)) {
+				best = i
+			}
+		}
+		if best < 0 {
+			r = append(r, fmt.Sprintf("no paystub with stock unit income of at least %.2f for the vest on %v",
+				v.Value(), vd.Format("2006-01-02")))
+			continue
+		}
+		ts[best].Vests = append(ts[best].Vests, v)
+		matched[best] += v.Value()
+	}
+	for i, t := range ts {
+		value, ok := matched[i]
+		if !ok {
+			continue
+		}
+		if math.Abs(float64(value-t.GoogStockUnit)) > float64(tolerance(shares(t.Vests))) {
+			r = append(r, fmt.Sprintf("paystub %v of %v: the vests are worth %.2f, but the stock unit income is %.2f",
+				t.DocNum, time.Time(t.Date).Format("2006-01-02"), value, t.GoogStockUnit))
+		}
+	}
+	return r
+}
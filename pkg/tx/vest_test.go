@@ -0,0 +1,64 @@
+package tx
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestReadVests(t *testing.T) {
+	t.Parallel()
+	in := `[{"Date": "2020-01-25", "Symbol": "GOOG", "Shares": 10.5, "WithheldShares": 4, "FMV": 1450.5}]`
+	actual, err := ReadVests(strings.NewReader(in))
+	if err != nil {
+		t.Fatalf("ReadVests: unexpected error: %v", err)
+	}
+	expected := []Vest{{Date: date("2020-01-25"), Symbol: "GOOG", Shares: 10.5, WithheldShares: 4, FMV: 1450.5}}
+	if diff := cmp.Diff(expected, actual, cmp.Comparer(DateOnly.Equal)); diff != "" {
+		t.Errorf("ReadVests(_)=%v, want: %v\ndiff:\n%v", actual, expected, diff)
+	}
+	if v := actual[0].NetValue(); v != 9428.25 {
+		t.Errorf("NetValue()=%v, want: 9428.25", v)
+	}
+}
+
+func TestMatchVests(t *testing.T) {
+	t.Parallel()
+	listed := Vest{Date: date("2020-02-25"), Shares: 1, FMV: 100}
+	ts := []Transaction{
+		{DocNum: "1", Date: date("2020-01-15"), GoogStockUnit: 100},
+		// No stock unit income.
+		{DocNum: "2", Date: date("2020-01-31")},
+		{DocNum: "3", Date: date("2020-02-15"), GoogStockUnit: 100},
+		// Lists its own vest.
+		{DocNum: "4", Date: date("2020-02-29"), GoogStockUnit: 100, Vests: []Vest{listed}},
+		// Pays out the vest on 2020-02-20, and has no stock unit income left
+		// for the one on 2020-03-20.
+		{DocNum: "5", Date: date("2020-03-31"), GoogStockUnit: 100},
+		// Some of the stock unit income is not accounted for.
+		{DocNum: "6", Date: date("2020-04-30"), GoogStockUnit: 100},
+	}
+	vs := []Vest{
+		{Date: date("2020-01-25"), Shares: 2, FMV: 20},
+		// Paid out on the same paystub as the vest before it.
+		{Date: date("2020-01-26"), Shares: 3, FMV: 20},
+		{Date: date("2020-02-20"), Shares: 4, FMV: 25},
+		{Date: date("2020-03-20"), Shares: 2, FMV: 60},
+		{Date: date("2020-04-20"), Shares: 1, FMV: 60},
+	}
+	warnings := MatchVests(ts, vs)
+	expected := [][]Vest{nil, nil, vs[:2], {listed}, vs[2:3], vs[4:]}
+	for i, e := range expected {
+		if diff := cmp.Diff(e, ts[i].Vests, cmp.Comparer(DateOnly.Equal)); diff != "" {
+			t.Errorf("MatchVests(_): paystub %v: diff:\n%v", ts[i].DocNum, diff)
+		}
+	}
+	expectedWarnings := []string{
+		"no paystub with stock unit income of at least 120.00 for the vest on 2020-03-20",
+		"paystub 6 of 2020-04-30: the vests are worth 60.00, but the stock unit income is 100.00",
+	}
+	if diff := cmp.Diff(expectedWarnings, warnings); diff != "" {
+		t.Errorf("MatchVests(_)=%v, want: %v\ndiff:\n%v", warnings, expectedWarnings, diff)
+	}
+}
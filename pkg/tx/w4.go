@@ -0,0 +1,69 @@
+package tx
+
+import "fmt"
+
+// W4 are the withholding settings that the employee chose on the federal W-4
+// and the state withholding forms, as the paystub lists them.
+type W4 struct {
+	FederalFilingStatus string `json:",omitempty"`
+	FederalAllowances   int    `json:",omitempty"`
+	// FederalExtra is the additional federal withholding per pay period.
+	FederalExtra      USD    `json:",omitempty"`
+	StateFilingStatus string `json:",omitempty"`
+	StateAllowances   int    `json:",omitempty"`
+	// StateExtra is the additional state withholding per pay period.
+	StateExtra USD `json:",omitempty"`
+}
+
+// IsZero returns true if no setting is known.
+func (w W4) IsZero() bool {
+	return w == W4{}
+}
+
+// Changes describes the settings that are different in w than in prev, e.g.
+// `federal filing status: "Single" -> "Married"`.
+func (w W4) Changes(prev W4) []string {
+	var r []string
+	add := func(name string, p, n interface{}) {
+		if p != n {
+			r = append(r, fmt.Sprintf("%v: %v -> %v", name, p, n))
+		}
+	}
+	add("federal filing status", fmt.Sprintf("%q", prev.FederalFilingStatus), fmt.Sprintf("%q", w.FederalFilingStatus))
+	add("federal allowances", prev.FederalAllowances, w.FederalAllowances)
+	add("federal extra withholding", prev.FederalExtra, w.FederalExtra)
+	add("state filing status", fmt.Sprintf("%q", prev.StateFilingStatus), fmt.Sprintf("%q", w.StateFilingStatus))
+	add("state allowances", prev.StateAllowances, w.StateAllowances)
+	add("state extra withholding", prev.StateExtra, w.StateExtra)
+	return r
+}
+
+// W4Change is a change of the withholding settings between two consecutive
+// paystubs.
+type W4Change struct {
+	// Prev is the paystub before the change, and T the first one after it.
+	Prev, T Transaction
+	// Changes describes the changed settings, see W4.Changes.
+	Changes []string
+}
+
+// W4Changes compares the withholding settings of consecutive transactions,
+// and returns all changes.  The transactions should be in date order.
+// Transactions without withholding settings are skipped.
+func W4Changes(ts []Transaction) []W4Change {
+	var r []W4Change
+	var prev *Transaction
+	for i := range ts {
+		t := &ts[i]
+		if t.W4.IsZero() {
+			continue
+		}
+		if prev != nil {
+			if c := t.W4.Changes(prev.W4); len(c) > 0 {
+				r = append(r, W4Change{Prev: *prev, T: *t, Changes: c})
+			}
+		}
+		prev = t
+	}
+	return r
+}
@@ -0,0 +1,37 @@
+package tx
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestW4Changes(t *testing.T) {
+	t.Parallel()
+	single := W4{FederalFilingStatus: "Single", StateFilingStatus: "Single", StateAllowances: 1}
+	married := single
+	married.FederalFilingStatus = "Married"
+	married.FederalExtra = 50
+	ts := []Transaction{
+		{DocNum: "1", W4: single},
+		{DocNum: "2", W4: single},
+		// Not listed on this one.
+		{DocNum: "3"},
+		{DocNum: "4", W4: married},
+		{DocNum: "5", W4: married},
+	}
+	cs := W4Changes(ts)
+	if len(cs) != 1 {
+		t.Fatalf("W4Changes(_)=%+v, want one change", cs)
+	}
+	if cs[0].Prev.DocNum != "2" || cs[0].T.DocNum != "4" {
+		t.Errorf("W4Changes(_) between %q and %q, want: 2 and 4", cs[0].Prev.DocNum, cs[0].T.DocNum)
+	}
+	expected := []string{
+		`federal filing status: "Single" -> "Married"`,
+		`federal extra withholding: 0.0000 USD -> 50.0000 USD`,
+	}
+	if diff := cmp.Diff(expected, cs[0].Changes); diff != "" {
+		t.Errorf("Changes=%v, want: %v\ndiff:\n%v", cs[0].Changes, expected, diff)
+	}
+}
@@ -0,0 +1,96 @@
+package tx
+
+import "math"
+
+// Taxes that the wages are computed for.  Pre-tax deductions are exempt from
+// some of them, but not necessarily all.
+const (
+	// TaxFederal is the federal income tax, W-2 box 1.
+	TaxFederal = "federal"
+	// TaxFICA are the social security and Medicare taxes, W-2 boxes 3 and
+	// 5.
+	TaxFICA = "fica"
+	// TaxState is the California income tax, W-2 box 16.
+	TaxState = "state"
+)
+
+// pretax maps the labels of the pre-tax deductions to the taxes they are
+// exempt from.  The deductions not listed here are after tax.
+var pretax = map[string][]string{
+	// Elective deferrals to a 401(k) are still subject to FICA.
+	"Bonus 401K Pre": {TaxFederal, TaxState},
+	// Section 125 cafeteria plan.
+	"Dental":     {TaxFederal, TaxFICA, TaxState},
+	"FSA Health": {TaxFederal, TaxFICA, TaxState},
+	"Medical":    {TaxFederal, TaxFICA, TaxState},
+	"Vision":     {TaxFederal, TaxFICA, TaxState},
+	// California does not recognize health savings accounts.
+	"HSA": {TaxFederal, TaxFICA},
+	// Section 132(f) qualified transportation fringe.
+	"Transit PreTax": {TaxFederal, TaxFICA, TaxState},
+}
+
+// Pretax returns true if the deduction with the given label is exempt from
+// the tax, one of the Tax* constants.
+func Pretax(label, tax string) bool {
+	for _, t := range pretax[label] {
+		if t == tax {
+			return true
+		}
+	}
+	return false
+}
+
+// Gross returns the sum of all earnings, including the imputed ones such as
+// group term life, and the stock unit vests.
+func (t Transaction) Gross() USD {
+	var g USD
+	for _, i := range t.Items() {
+		if i.Section == SectionEarnings {
+			g += i.Amount
+		}
+	}
+	return g
+}
+
+// Wages returns the wages subject to the tax, one of the Tax* constants: the
+// gross pay less the pre-tax deductions that are exempt from it.  The social
+// security wage base is not taken into account.
+func (t Transaction) Wages(tax string) USD {
+	w := t.Gross()
+	for _, i := range t.Items() {
+		if i.Section == SectionDeductions && Pretax(i.Label, tax) {
+			w -= i.Amount
+		}
+	}
+	return w
+}
+
+// Imputed returns the imputed income on the paystub: the value of a benefit
+// that is taxable, but not paid out, so that the paystub adds it to the
+// earnings and takes it back with a deduction of the same label.  The group
+// term life insurance over $50,000 is the only such benefit on the paystubs.
+// Only the part that the deduction offsets is imputed; if the amounts differ
+// the rest is an ordinary earning or deduction.
+func (t Transaction) Imputed() USD {
+	i, e := t.IGroupTermLife, t.EGroupTermLife
+	if (i > 0) != (e > 0) {
+		return 0
+	}
+	if math.Abs(float64(i)) < math.Abs(float64(e)) {
+		return i
+	}
+	return e
+}
+
+// Supplemental are the supplemental wages of a paystub, such as bonuses and
+// stock vests, which are withheld on at flat rates, and the income tax
+// withheld on them.
+type Supplemental struct {
+	// Wages and StateWages are the supplemental wages subject to the federal
+	// and the state income tax.
+	Wages            USD `json:",omitempty"`
+	StateWages       USD `json:",omitempty"`
+	FederalIncomeTax USD `json:",omitempty"`
+	CAStateIncomeTax USD `json:",omitempty"`
+}
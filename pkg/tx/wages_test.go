@@ -0,0 +1,48 @@
+package tx
+
+import "testing"
+
+func TestWages(t *testing.T) {
+	t.Parallel()
+	tr := Transaction{
+		RegularPay:     1000,
+		IGroupTermLife: 10,
+		Bonus401kPre:   100,
+		Medical:        20,
+		HSA:            30,
+		VolLifeEE:      5,
+	}
+	tr.UnknownByName(SectionEarnings, "Shiny Bonus", 50)
+	if g := tr.Gross(); g != 1060 {
+		t.Errorf("Gross()=%v, want: 1060", g)
+	}
+	for tax, expected := range map[string]USD{
+		TaxFederal: 910,
+		TaxFICA:    1010,
+		TaxState:   940,
+	} {
+		if w := tr.Wages(tax); w != expected {
+			t.Errorf("Wages(%q)=%v, want: %v", tax, w, expected)
+		}
+	}
+}
+
+func TestImputed(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		income, deduction, expected USD
+	}{
+		{10, 10, 10},
+		{10, 4, 4},
+		{4, 10, 4},
+		{-10, -10, -10},
+		{10, 0, 0},
+		{10, -10, 0},
+	}
+	for _, test := range tests {
+		tr := Transaction{IGroupTermLife: test.income, EGroupTermLife: test.deduction}
+		if actual := tr.Imputed(); actual != test.expected {
+			t.Errorf("Imputed(%v, %v)=%v, want: %v", test.income, test.deduction, actual, test.expected)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package w2
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/filmil/fintools-public/pkg/tx"
+)
+
+// Accounts are the accounts that the paystubs book the withheld taxes to.  As
+// in out.Config, each one is a format with the year as the argument, for
+// example "Expenses:Taxes:Y%s:FederalIncomeTax".  Empty accounts get no
+// balance assertion.
+type Accounts struct {
+	FederalIncomeTax  string
+	SocialSecurityTax string
+	MedicareTax       string
+	StateIncomeTax    string
+}
+
+// balance is a beancount balance assertion.
+type balance struct {
+	Account string
+	Amount  tx.USD
+}
+
+// meta is a beancount metadata entry.
+type meta struct {
+	Key   string
+	Value string
+}
+
+type beancount struct {
+	W        W2
+	End      string
+	Next     string
+	Meta     []meta
+	Balances []balance
+}
+
+var beancountTpl = template.Must(template.New("w2").Parse(`{{.End}} custom "w2" "{{.W.EmployerEIN}}"{{range .Meta}}
+   {{.Key}}: {{.Value}}{{end}}
+{{range .Balances}}{{$.Next}} balance {{.Account}} {{.Amount}}
+{{end}}`))
+
+// Beancount writes the W-2 as a beancount custom "w2" entry on the last day of
+// the tax year, with all the boxes as metadata.  The withheld taxes are
+// asserted as the balances of the tax accounts on the first day of the next
+// year, which checks the paystubs of the year against the W-2.
+func Beancount(out io.Writer, w W2, a Accounts) error {
+	if w.Year == 0 {
+		return fmt.Errorf("the W-2 has no tax year")
+	}
+	y := fmt.Sprint(w.Year)
+	b := beancount{
+		W:    w,
+		End:  fmt.Sprintf("%d-12-31", w.Year),
+		Next: fmt.Sprintf("%d-01-01", w.Year+1),
+	}
+	amount := func(k string, v tx.USD) {
+		b.Meta = append(b.Meta, meta{k, v.String()})
+	}
+	amount("wages", w.Wages)
+	amount("federal-income-tax", w.FederalIncomeTax)
+	amount("social-security-wages", w.SocialSecurityWages)
+	amount("social-security-tax", w.SocialSecurityTax)
+	amount("medicare-wages", w.MedicareWages)
+	amount("medicare-tax", w.MedicareTax)
+	var codes []string
+	for c := range w.Box12 {
+		codes = append(codes, c)
+	}
+	sort.Strings(codes)
+	for _, c := range codes {
+		amount("box-12-"+strings.ToLower(c), w.Box12[c])
+	}
+	if w.State != "" {
+		b.Meta = append(b.Meta, meta{"state", fmt.Sprintf("%q", w.State)})
+	}
+	if w.StateEmployerID != "" {
+		b.Meta = append(b.Meta, meta{"state-employer-id", fmt.Sprintf("%q", w.StateEmployerID)})
+	}
+	amount("state-wages", w.StateWages)
+	amount("state-income-tax", w.StateIncomeTax)
+	for _, f := range []struct {
+		account string
+		v       tx.USD
+	}{
+		{a.FederalIncomeTax, w.FederalIncomeTax},
+		{a.SocialSecurityTax, w.SocialSecurityTax},
+		{a.MedicareTax, w.MedicareTax},
+		{a.StateIncomeTax, w.StateIncomeTax},
+	} {
+		if f.account != "" {
+			b.Balances = append(b.Balances, balance{fmt.Sprintf(f.account, y), f.v})
+		}
+	}
+	return beancountTpl.Execute(out, b)
+}
@@ -0,0 +1,52 @@
+package w2
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/filmil/fintools-public/pkg/tx"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestBeancount(t *testing.T) {
+	t.Parallel()
+	w := W2{
+		Year:             2020,
+		EmployerEIN:      "12-3456789",
+		Wages:            150000,
+		FederalIncomeTax: 30000,
+		MedicareTax:      2175,
+		Box12:            map[string]tx.USD{CodeDD: 8000, CodeD: 19500},
+		State:            "CA",
+		StateIncomeTax:   9876.54,
+	}
+	a := Accounts{
+		FederalIncomeTax: "Expenses:Taxes:Y%s:FederalIncomeTax",
+		MedicareTax:      "Expenses:Taxes:Y%s:EmployeeMedicare",
+	}
+	var b strings.Builder
+	if err := Beancount(&b, w, a); err != nil {
+		t.Fatalf("Beancount: unexpected error: %v", err)
+	}
+	expected := `2020-12-31 custom "w2" "12-3456789"
+   wages: 150000.0000 USD
+   federal-income-tax: 30000.0000 USD
+   social-security-wages: 0.0000 USD
+   social-security-tax: 0.0000 USD
+   medicare-wages: 0.0000 USD
+   medicare-tax: 2175.0000 USD
+   box-12-d: 19500.0000 USD
+   box-12-dd: 8000.0000 USD
+   state: "CA"
+   state-wages: 0.0000 USD
+   state-income-tax: 9876.5400 USD
+2021-01-01 balance Expenses:Taxes:Y2020:FederalIncomeTax 30000.0000 USD
+2021-01-01 balance Expenses:Taxes:Y2020:EmployeeMedicare 2175.0000 USD
+`
+	if diff := cmp.Diff(expected, b.String()); diff != "" {
+		t.Errorf("Beancount(_)=\n%v\nwant:\n%v\ndiff:\n%v", b.String(), expected, diff)
+	}
+	if err := Beancount(&b, W2{}, a); err == nil {
+		t.Errorf("Beancount(W2{}): want error for a missing year")
+	}
+}
@@ -0,0 +1,97 @@
+package w2
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/filmil/fintools-public/pkg/limits"
+	"github.com/filmil/fintools-public/pkg/tx"
+)
+
+// Expected returns the W-2 boxes expected from the paystubs of the year.
+// Paystubs paid in other years are skipped.
+func Expected(ts []tx.Transaction, year int) W2 {
+	w := W2{Year: year}
+	b12 := map[string]tx.USD{}
+	for _, t := range ts {
+		if time.Time(t.Date).Year() != year {
+			continue
+		}
+		w.Wages += t.Wages(tx.TaxFederal)
+		w.FederalIncomeTax += t.FederalIncomeTax
+		w.SocialSecurityWages += t.Wages(tx.TaxFICA)
+		w.SocialSecurityTax += t.SocialSecurityEmployeeTax
+		w.MedicareWages += t.Wages(tx.TaxFICA)
+		w.MedicareTax += t.EmployeeMedicare
+		w.StateWages += t.Wages(tx.TaxState)
+		w.StateIncomeTax += t.CAStateIncomeTax
+		b12[CodeC] += t.IGroupTermLife
+		b12[CodeD] += t.Bonus401kPre
+		b12[CodeDD] += t.Medical + t.Dental + t.Vision +
+			t.Employer.Medical + t.Employer.Dental + t.Employer.Vision
+		b12[CodeW] += t.HSA + t.Employer.HSA
+	}
+	if l, err := limits.For(year); err == nil && w.SocialSecurityWages > l.SocialSecurityWageBase {
+		w.SocialSecurityWages = l.SocialSecurityWageBase
+	}
+	for c, v := range b12 {
+		if v == 0 {
+			continue
+		}
+		if w.Box12 == nil {
+			w.Box12 = map[string]tx.USD{}
+		}
+		w.Box12[c] = v
+	}
+	return w
+}
+
+// Diff is a W-2 box whose value differs from the one expected from the
+// paystubs.
+type Diff struct {
+	// Box is the name of the box, e.g. "1" or "12 D".
+	Box      string
+	Expected tx.USD
+	Actual   tx.USD
+}
+
+func (d Diff) String() string {
+	return fmt.Sprintf("box %v: paystubs %.2f, W-2 %.2f, difference %.2f",
+		d.Box, d.Expected, d.Actual, d.Actual-d.Expected)
+}
+
+// Reconcile returns the boxes of the W-2 w that differ by more than a cent
+// from the ones expected from the paystubs ts of the year.
+func Reconcile(w W2, ts []tx.Transaction, year int) []Diff {
+	e := Expected(ts, year)
+	var r []Diff
+	add := func(box string, expected, actual tx.USD) {
+		if math.Abs(float64(expected-actual)) > 0.005 {
+			r = append(r, Diff{Box: box, Expected: expected, Actual: actual})
+		}
+	}
+	add("1", e.Wages, w.Wages)
+	add("2", e.FederalIncomeTax, w.FederalIncomeTax)
+	add("3", e.SocialSecurityWages, w.SocialSecurityWages)
+	add("4", e.SocialSecurityTax, w.SocialSecurityTax)
+	add("5", e.MedicareWages, w.MedicareWages)
+	add("6", e.MedicareTax, w.MedicareTax)
+	var codes []string
+	for c := range e.Box12 {
+		codes = append(codes, c)
+	}
+	for c := range w.Box12 {
+		if _, ok := e.Box12[c]; !ok {
+			codes = append(codes, c)
+		}
+	}
+	sort.Strings(codes)
+	for _, c := range codes {
+		add("12 "+c, e.Box12[c], w.Box12[c])
+	}
+	add("16", e.StateWages, w.StateWages)
+	add("17", e.StateIncomeTax, w.StateIncomeTax)
+	return r
+}
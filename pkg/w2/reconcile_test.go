@@ -0,0 +1,43 @@
+package w2
+
+import (
+	"testing"
+	"time"
+
+	"github.com/filmil/fintools-public/pkg/tx"
+	"github.com/google/go-cmp/cmp"
+)
+
+func paystub(year int, regular tx.USD) tx.Transaction {
+	return tx.Transaction{
+		Date:             tx.DateOnly(time.Date(year, 6, 15, 0, 0, 0, 0, time.UTC)),
+		RegularPay:       regular,
+		IGroupTermLife:   10,
+		Bonus401kPre:     1000,
+		Medical:          100,
+		HSA:              50,
+		CAStateIncomeTax: 500,
+		Employer:         tx.Employer{Medical: 300, HSA: 25},
+	}
+}
+
+func TestReconcile(t *testing.T) {
+	t.Parallel()
+	ts := []tx.Transaction{paystub(2020, 100000), paystub(2020, 50000), paystub(2019, 1)}
+	w := W2{
+		Wages:               147620,
+		SocialSecurityWages: 137700,
+		MedicareWages:       149720,
+		Box12:               map[string]tx.USD{CodeC: 20, CodeD: 2000, CodeDD: 800, CodeW: 150, "AA": 10},
+		StateWages:          147820,
+		StateIncomeTax:      1000,
+	}
+	expected := []Diff{
+		{Box: "1", Expected: 147720, Actual: 147620},
+		{Box: "12 AA", Expected: 0, Actual: 10},
+	}
+	actual := Reconcile(w, ts, 2020)
+	if diff := cmp.Diff(expected, actual); diff != "" {
+		t.Errorf("Reconcile(_)=%v, want: %v\ndiff:\n%v", actual, expected, diff)
+	}
+}
@@ -0,0 +1,286 @@
+// Package w2 parses the W-2 wage and tax statement from the pdf2txt XML, and
+// reconciles it with the paystubs of the year.
+//
+// The W-2 is a fixed form, so each box is found by its label, and its value
+// is the textline right below the label.  If the page has several copies of
+// the form, the top left one is used.  The state boxes 15 to 17 are parsed for
+// the first state only.
+package w2
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/filmil/fintools-public/pkg/tx"
+	"github.com/filmil/fintools-public/pkg/xml"
+	"github.com/pkg/errors"
+)
+
+// W2 are the boxes of a W-2 form.
+type W2 struct {
+	// Year is the tax year, or zero if the form does not say.
+	Year int `json:",omitempty"`
+	// EmployerEIN is box b, the employer identification number.
+	EmployerEIN string `json:",omitempty"`
+	// Wages is box 1, wages, tips, other compensation.
+	Wages tx.USD `json:",omitempty"`
+	// FederalIncomeTax is box 2, the federal income tax withheld.
+	FederalIncomeTax tx.USD `json:",omitempty"`
+	// SocialSecurityWages is box 3.
+	SocialSecurityWages tx.USD `json:",omitempty"`
+	// SocialSecurityTax is box 4, the social security tax withheld.
+	SocialSecurityTax tx.USD `json:",omitempty"`
+	// MedicareWages is box 5, Medicare wages and tips.
+	MedicareWages tx.USD `json:",omitempty"`
+	// MedicareTax is box 6, the Medicare tax withheld.
+	MedicareTax tx.USD `json:",omitempty"`
+	// Box12 maps the box 12 codes, such as "D", to their amounts.
+	Box12 map[string]tx.USD `json:",omitempty"`
+	// State and StateEmployerID are box 15.
+	State           string `json:",omitempty"`
+	StateEmployerID string `json:",omitempty"`
+	// StateWages is box 16, state wages, tips, etc.
+	StateWages tx.USD `json:",omitempty"`
+	// StateIncomeTax is box 17.
+	StateIncomeTax tx.USD `json:",omitempty"`
+}
+
+// Box 12 codes.
+const (
+	// CodeC is the taxable cost of group-term life insurance over $50,000.
+	CodeC = "C"
+	// CodeD are the elective deferrals to a 401(k).
+	CodeD = "D"
+	// CodeDD is the cost of the employer-sponsored health coverage.
+	CodeDD = "DD"
+	// CodeW are the employer contributions to a health savings account.
+	CodeW = "W"
+)
+
+// boxHeight is how far below its label the value of a box may be, in
+// points.
+const boxHeight = 24
+
+// box12Width is the width of a box 12 entry, from its label to the right
+// edge of the form.
+const box12Width = 150
+
+// eps is the slack in the comparison of coordinates.
+const eps = 0.5
+
+// box12Labels are the labels of the box 12 entries.
+var box12Labels = []string{"12a", "12b", "12c", "12d"}
+
+// boxLabel matches the start of a box label, which is the box number or
+// letter, e.g. "12a" or "b Employer identification number".
+var boxLabel = regexp.MustCompile(`^(\d{1,2}[a-d]?|[a-f])( |$)`)
+
+// code matches a box 12 code.
+var code = regexp.MustCompile(`^[A-Z]{1,2}$`)
+
+// first returns the top left one of the textlines.
+func first(tls []xml.Textline) (xml.Textline, bool) {
+	if len(tls) == 0 {
+		return xml.Textline{}, false
+	}
+	f := tls[0]
+	for _, t := range tls[1:] {
+		if t.BBox.Top > f.BBox.Top+eps || (t.BBox.Top > f.BBox.Top-eps && t.BBox.Left < f.BBox.Left) {
+			f = t
+		}
+	}
+	return f, true
+}
+
+// below returns the textlines in the box right below the label, widened to
+// the left by left points.
+func below(tls []xml.Textline, l xml.Textline, left float64) []xml.Textline {
+	b := l.BBox
+	b.Left -= left
+	b = b.Below(l.BBox)
+	b.Bottom = l.BBox.Bottom - boxHeight
+	return xml.FindInBBox(tls, b)
+}
+
+// boxText returns the text in the box with the label, or "" if the box is
+// empty.
+func boxText(tls []xml.Textline, label string) (string, error) {
+	l, ok := first(xml.MatchPredicate(tls, xml.Containing(label)))
+	if !ok {
+		return "", errors.Errorf("no box %q", label)
+	}
+	v, ok := first(below(tls, l, boxHeight/2))
+	if !ok {
+		return "", nil
+	}
+	return v.Text(), nil
+}
+
+// boxValue returns the amount in the box with the label, or zero if the box
+// is empty.
+func boxValue(tls []xml.Textline, label string) (tx.USD, error) {
+	s, err := boxText(tls, label)
+	if err != nil || s == "" {
+		return 0, err
+	}
+	a, err := xml.ParseAmount(s)
+	if err != nil {
+		if boxLabel.MatchString(s) {
+			// The label of the next box, the box is empty.
+			return 0, nil
+		}
+		return 0, errors.Wrapf(err, "box %q", label)
+	}
+	return a, nil
+}
+
+// ein matches an employer identification number.
+var ein = regexp.MustCompile(`^\d\d-\d{7}$`)
+
+// state matches a state abbreviation.
+var state = regexp.MustCompile(`^[A-Z]{2}$`)
+
+// year matches a tax year.
+var year = regexp.MustCompile(`\b20\d\d\b`)
+
+// stateWidth is the width of the state column of box 15, left of the
+// employer's state ID number.
+const stateWidth = 40
+
+// box15 parses the state and the employer's state ID number from box 15,
+// which is a single row under the label.  The box is optional, since some
+// states have no income tax.
+func box15(tls []xml.Textline, w *W2) {
+	l, ok := first(xml.MatchPredicate(tls, xml.Containing("state ID number")))
+	if !ok {
+		return
+	}
+	var f []string
+	for _, t := range xml.SortLeft(below(tls, l, stateWidth)) {
+		f = append(f, strings.Fields(t.Text())...)
+	}
+	if len(f) > 0 && state.MatchString(f[0]) {
+		w.State = f[0]
+		f = f[1:]
+	}
+	w.StateEmployerID = strings.Join(f, " ")
+}
+
+// taxYear returns the tax year from the form title, or right of it.
+func taxYear(tls []xml.Textline) int {
+	l, ok := first(xml.MatchPredicate(tls, xml.Containing("Wage and Tax Statement")))
+	if !ok {
+		return 0
+	}
+	b := l.BBox.ExtendRight()
+	b.Left = l.BBox.Left
+	b.Top += boxHeight / 2
+	b.Bottom -= boxHeight / 2
+	for _, t := range xml.SortLeft(xml.FindInBBox(tls, b)) {
+		if y := year.FindString(t.Text()); y != "" {
+			n, _ := strconv.Atoi(y)
+			return n
+		}
+	}
+	return 0
+}
+
+// box12 adds the code and amount of the box 12 entry with the label to w.
+// The code and the amount are in the same row as the label, or slightly
+// below it, either as one textline or as two.  The label textline itself has
+// no code, so it is skipped.
+func box12(tls []xml.Textline, label string, w *W2) error {
+	l, ok := first(xml.MatchPredicate(tls, func(t xml.Textline) bool {
+		s := t.Text()
+		return s == label || strings.HasPrefix(s, label+" ")
+	}))
+	if !ok {
+		return nil
+	}
+	b := l.BBox
+	b.Left += eps
+	b.Right = l.BBox.Left + box12Width
+	b.Top = l.BBox.Top + eps
+	b.Bottom = l.BBox.Bottom - boxHeight/2
+	var c string
+	var a tx.USD
+	found := false
+	for _, t := range xml.SortLeft(xml.FindInBBox(tls, b)) {
+		f := strings.Fields(t.Text())
+		if len(f) == 0 || len(f) > 2 {
+			continue
+		}
+		if code.MatchString(f[0]) {
+			c = f[0]
+			f = f[1:]
+		}
+		if len(f) == 1 {
+			v, err := xml.ParseAmount(f[0])
+			if err != nil {
+				continue
+			}
+			a, found = v, true
+		}
+	}
+	if c == "" {
+		return nil
+	}
+	if !found {
+		return errors.Errorf("box %v: no amount for code %q", label, c)
+	}
+	if w.Box12 == nil {
+		w.Box12 = map[string]tx.USD{}
+	}
+	w.Box12[c] += a
+	return nil
+}
+
+// Parse parses the W-2 form on the first page of p.
+func Parse(p xml.Paystub) (W2, error) {
+	var w W2
+	if len(p.Pages) == 0 {
+		return w, errors.Errorf("no pages")
+	}
+	tls := xml.Textlines(p.Pages[0])
+	w.Year = taxYear(tls)
+	e, err := boxText(tls, "Employer identification number")
+	if err != nil {
+		return w, errors.Wrapf(err, "while parsing the W-2")
+	}
+	if e != "" && !ein.MatchString(e) {
+		return w, errors.Errorf("not an EIN: %q", e)
+	}
+	w.EmployerEIN = e
+	for _, f := range []struct {
+		label string
+		v     *tx.USD
+		// optional boxes may be left out of the form.
+		optional bool
+	}{
+		{"Wages, tips, other compensation", &w.Wages, false},
+		{"Federal income tax withheld", &w.FederalIncomeTax, false},
+		{"Social security wages", &w.SocialSecurityWages, false},
+		{"Social security tax withheld", &w.SocialSecurityTax, false},
+		{"Medicare wages and tips", &w.MedicareWages, false},
+		{"Medicare tax withheld", &w.MedicareTax, false},
+		{"State wages, tips", &w.StateWages, true},
+		{"State income tax", &w.StateIncomeTax, true},
+	} {
+		v, err := boxValue(tls, f.label)
+		if err != nil && f.optional {
+			continue
+		}
+		if err != nil {
+			return w, errors.Wrapf(err, "while parsing the W-2")
+		}
+		*f.v = v
+	}
+	for _, l := range box12Labels {
+		if err := box12(tls, l, &w); err != nil {
+			return w, errors.Wrapf(err, "while parsing the W-2")
+		}
+	}
+	box15(tls, &w)
+	return w, nil
+}
@@ -0,0 +1,68 @@
+package w2
+
+import (
+	"testing"
+
+	"github.com/filmil/fintools-public/pkg/tx"
+	"github.com/filmil/fintools-public/pkg/xml"
+	"github.com/google/go-cmp/cmp"
+)
+
+// form returns a W-2 page with the textlines.
+func form(tls ...xml.Textline) xml.Paystub {
+	return xml.Paystub{Pages: []xml.Page{{Textboxes: []xml.Textbox{{Textlines: tls}}}}}
+}
+
+func TestParse(t *testing.T) {
+	t.Parallel()
+	p := form(
+		xml.At("Form W-2 Wage and Tax Statement", 100, 200), xml.At("2020", 300, 198),
+		xml.At("b Employer identification number (EIN)", 100, 700), xml.At("12-3456789", 100, 685),
+		xml.At("1 Wages, tips, other compensation", 300, 700), xml.At("150,000.00", 300, 685),
+		xml.At("3 Social security wages", 300, 670), xml.At("137700.00", 300, 655),
+		xml.At("5 Medicare wages and tips", 300, 640),
+		xml.At("12a See instructions for box 12", 450, 640), xml.At("D", 470, 630), xml.At("19,500.00", 490, 630),
+		xml.At("12b", 450, 610), xml.At("DD 8000.00", 470, 605),
+		xml.At("12c", 450, 580),
+		xml.At("2 Federal income tax withheld", 450, 700), xml.At("30,000.00", 450, 685),
+		xml.At("4 Social security tax withheld", 600, 670), xml.At("8,537.40", 600, 655),
+		xml.At("6 Medicare tax withheld", 600, 640),
+		xml.At("15 State", 100, 560), xml.At("Employer's state ID number", 130, 560),
+		xml.At("CA", 100, 545), xml.At("123-4567-8", 130, 545),
+		xml.At("16 State wages, tips, etc.", 250, 560), xml.At("140,000.00", 250, 545),
+		xml.At("17 State income tax", 400, 560), xml.At("9,876.54", 400, 545),
+		// A second copy of the form, below the first one.
+		xml.At("1 Wages, tips, other compensation", 300, 300), xml.At("1.00", 300, 285),
+	)
+	actual, err := Parse(p)
+	if err != nil {
+		t.Fatalf("Parse: unexpected error: %v", err)
+	}
+	expected := W2{
+		Year:                2020,
+		EmployerEIN:         "12-3456789",
+		Wages:               150000,
+		FederalIncomeTax:    30000,
+		SocialSecurityWages: 137700,
+		SocialSecurityTax:   8537.40,
+		Box12:               map[string]tx.USD{CodeD: 19500, CodeDD: 8000},
+		State:               "CA",
+		StateEmployerID:     "123-4567-8",
+		StateWages:          140000,
+		StateIncomeTax:      9876.54,
+	}
+	if diff := cmp.Diff(expected, actual); diff != "" {
+		t.Errorf("Parse(_)=%+v, want: %+v\ndiff:\n%v", actual, expected, diff)
+	}
+}
+
+func TestParseNotAnAmount(t *testing.T) {
+	t.Parallel()
+	p := form(
+		xml.At("b Employer identification number (EIN)", 100, 700), xml.At("12-3456789", 100, 685),
+		xml.At("1 Wages, tips, other compensation", 300, 700), xml.At("see attached", 300, 685),
+	)
+	if w, err := Parse(p); err == nil {
+		t.Errorf("Parse(_)=%+v, want error", w)
+	}
+}
@@ -0,0 +1,148 @@
+// Package withholding projects the income tax withheld on the paystubs to the
+// end of the year, and compares it with a simple model of the federal and the
+// California income tax, and with the estimated tax safe harbors.
+//
+// The model only knows wages: it takes the standard deduction, and leaves out
+// credits, other income and the alternative minimum tax.
+package withholding
+
+import (
+	"fmt"
+
+	"github.com/filmil/fintools-public/pkg/tx"
+)
+
+// Filing statuses.
+const (
+	StatusSingle = "single"
+	// StatusMarried is married filing jointly.
+	StatusMarried = "married"
+)
+
+// Bracket is a tax bracket: the rate on the taxable income over Over, up to
+// the next bracket.
+type Bracket struct {
+	Over tx.USD
+	Rate float64
+}
+
+// Schedule is the tax schedule of a year and a filing status.
+type Schedule struct {
+	// Deduction is the standard deduction.
+	Deduction tx.USD
+	Brackets  []Bracket
+	// Surcharges are additional rates on all of the taxable income over
+	// their Over.
+	Surcharges []Bracket
+}
+
+// Tax returns the tax on the wages.
+func (s Schedule) Tax(wages tx.USD) tx.USD {
+	income := wages - s.Deduction
+	var tax float64
+	for i, b := range s.Brackets {
+		if income <= b.Over {
+			break
+		}
+		top := income
+		if i+1 < len(s.Brackets) && s.Brackets[i+1].Over < top {
+			top = s.Brackets[i+1].Over
+		}
+		tax += float64(top-b.Over) * b.Rate
+	}
+	for _, b := range s.Surcharges {
+		if income > b.Over {
+			tax += float64(income-b.Over) * b.Rate
+		}
+	}
+	return tx.Round(tx.USD(tax))
+}
+
+var federalRates = []float64{0.10, 0.12, 0.22, 0.24, 0.32, 0.35, 0.37}
+
+// federalSchedule returns the federal schedule with the given standard
+// deduction and lower bounds of the brackets above the first.
+func federalSchedule(deduction tx.USD, overs ...tx.USD) Schedule {
+	s := Schedule{Deduction: deduction}
+	for i, r := range federalRates {
+		var over tx.USD
+		if i > 0 {
+			over = overs[i-1]
+		}
+		s.Brackets = append(s.Brackets, Bracket{over, r})
+	}
+	return s
+}
+
+var stateRates = []float64{0.01, 0.02, 0.04, 0.06, 0.08, 0.093, 0.103, 0.113, 0.123}
+
+// mentalHealthServicesTax is the California tax of 1% on the taxable income
+// over one million dollars, for all filing statuses.
+var mentalHealthServicesTax = Bracket{1000000, 0.01}
+
+// stateSchedules returns the California schedules with the given single
+// standard deduction and lower bounds of the brackets above the first.  The
+// married brackets and deduction are twice the single ones.
+func stateSchedules(deduction tx.USD, overs ...tx.USD) map[string]Schedule {
+	single := Schedule{Deduction: deduction}
+	married := Schedule{Deduction: 2 * deduction}
+	for i, r := range stateRates {
+		var over tx.USD
+		if i > 0 {
+			over = overs[i-1]
+		}
+		single.Brackets = append(single.Brackets, Bracket{over, r})
+		married.Brackets = append(married.Brackets, Bracket{2 * over, r})
+	}
+	single.Surcharges = []Bracket{mentalHealthServicesTax}
+	married.Surcharges = []Bracket{mentalHealthServicesTax}
+	return map[string]Schedule{StatusSingle: single, StatusMarried: married}
+}
+
+// federal are the federal schedules by year and filing status.
+var federal = map[int]map[string]Schedule{
+	2023: {
+		StatusSingle:  federalSchedule(13850, 11000, 44725, 95375, 182100, 231250, 578125),
+		StatusMarried: federalSchedule(27700, 22000, 89450, 190750, 364200, 462500, 693750),
+	},
+	2024: {
+		StatusSingle:  federalSchedule(14600, 11600, 47150, 100525, 191950, 243725, 609350),
+		StatusMarried: federalSchedule(29200, 23200, 94300, 201050, 383900, 487450, 731200),
+	},
+	2025: {
+		StatusSingle:  federalSchedule(15750, 11925, 48475, 103350, 197300, 250525, 626350),
+		StatusMarried: federalSchedule(31500, 23850, 96950, 206700, 394600, 501050, 751600),
+	},
+	2026: {
+		StatusSingle:  federalSchedule(16100, 12400, 50400, 105700, 201775, 256225, 640600),
+		StatusMarried: federalSchedule(32200, 24800, 100800, 211400, 403550, 512450, 768700),
+	},
+}
+
+// state are the California schedules by year and filing status.
+var state = map[int]map[string]Schedule{
+	2023: stateSchedules(5363, 10412, 24684, 38959, 54081, 68350, 349137, 418961, 698271),
+	2024: stateSchedules(5540, 10756, 25499, 40245, 55866, 70606, 360659, 432787, 721314),
+	2025: stateSchedules(5706, 11079, 26264, 41452, 57542, 72724, 371479, 445771, 742953),
+}
+
+// schedule returns the schedule of the year and the filing status from the
+// table, and the year that it is for.  A year that is not in the table gets
+// the schedule of the last year before it that is.
+func schedule(table map[int]map[string]Schedule, year int, status string) (Schedule, int, error) {
+	y := year
+	for ; y > year-10; y-- {
+		if _, ok := table[y]; ok {
+			break
+		}
+	}
+	ss, ok := table[y]
+	if !ok {
+		return Schedule{}, 0, fmt.Errorf("no tax brackets known for year %v", year)
+	}
+	s, ok := ss[status]
+	if !ok {
+		return Schedule{}, 0, fmt.Errorf("unknown filing status %q, want %v or %v", status, StatusSingle, StatusMarried)
+	}
+	return s, y, nil
+}
@@ -0,0 +1,85 @@
+package withholding
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	"github.com/filmil/fintools-public/pkg/tx"
+)
+
+// Output formats.
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+func ymd(d time.Time) string {
+	return d.Format("2006-01-02")
+}
+
+// text writes the projection as a table with a column for the federal and
+// the state tax, followed by the suggested estimated payments.
+func text(w io.Writer, p Projection) error {
+	fmt.Fprintf(w, "%v as of %v, %d paydays left\n", p.Year, ymd(time.Time(p.AsOf)), p.Paydays)
+	for _, n := range []struct {
+		name string
+		e    Estimate
+	}{{"federal", p.Federal}, {"California", p.State}} {
+		if n.e.BracketYear != p.Year {
+			fmt.Fprintf(w, "The %v brackets of %v are not known, using those of %v.\n", n.name, p.Year, n.e.BracketYear)
+		}
+	}
+	fmt.Fprintln(w)
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "\tFederal\tCalifornia\n")
+	f, s := p.Federal, p.State
+	for _, r := range []struct {
+		name string
+		f, s tx.USD
+	}{
+		{"Wages so far", f.WagesYTD, s.WagesYTD},
+		{"Withheld so far", f.WithheldYTD, s.WithheldYTD},
+		{"Projected wages", f.Wages, s.Wages},
+		{"Projected withholding", f.Withheld, s.Withheld},
+		{"Projected tax", f.Tax, s.Tax},
+		{"Balance due", f.Tax - f.Withheld, s.Tax - s.Withheld},
+		{"Safe harbor", f.SafeHarbor, s.SafeHarbor},
+		{"Short of safe harbor", f.Short, s.Short},
+		{"Extra per payday", f.ExtraPerPayday, s.ExtraPerPayday},
+	} {
+		fmt.Fprintf(tw, "%v\t%.2f\t%.2f\n", r.name, r.f, r.s)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "\nFederal safe harbor: %v.\nCalifornia safe harbor: %v.\n", f.SafeHarborRule, s.SafeHarborRule)
+	for _, n := range []struct {
+		name string
+		ps   []Payment
+	}{{"Federal", f.Payments}, {"California", s.Payments}} {
+		if len(n.ps) == 0 {
+			continue
+		}
+		fmt.Fprintf(w, "\n%v estimated payments, or the extra withholding per payday above:\n", n.name)
+		for _, pm := range n.ps {
+			fmt.Fprintf(w, "  %v  %.2f\n", ymd(time.Time(pm.Due)), pm.Amount)
+		}
+	}
+	return nil
+}
+
+// Write writes the projection in the format, one of the Format* constants.
+func Write(w io.Writer, p Projection, format string) error {
+	switch format {
+	case FormatText:
+		return text(w, p)
+	case FormatJSON:
+		e := json.NewEncoder(w)
+		e.SetIndent("", "  ")
+		return e.Encode(p)
+	}
+	return fmt.Errorf("unknown format %q, want %v or %v", format, FormatText, FormatJSON)
+}
@@ -0,0 +1,240 @@
+package withholding
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/filmil/fintools-public/pkg/limits"
+	"github.com/filmil/fintools-public/pkg/tx"
+)
+
+// Prior is the tax of the prior year, from its returns.  A negative tax is
+// not known, and its prior year safe harbor does not apply.
+type Prior struct {
+	FederalTax tx.USD
+	StateTax   tx.USD
+	// AGI is the adjusted gross income.
+	AGI tx.USD
+}
+
+// Options modify the projection.
+type Options struct {
+	// Status is the filing status, one of the Status* constants.
+	Status string
+	// Prior is the prior year, or nil if it is not known.  Without it, only
+	// the safe harbor of the current year applies.
+	Prior *Prior
+}
+
+// Payment is an estimated tax payment.
+type Payment struct {
+	Due    tx.DateOnly
+	Amount tx.USD
+}
+
+// Estimate is the projection of one of the income taxes.
+type Estimate struct {
+	// BracketYear is the year of the brackets that Tax is computed with,
+	// which is the projected year unless its brackets are not known yet.
+	BracketYear int
+	// WagesYTD and WithheldYTD are the taxable wages and the tax withheld on
+	// the paystubs so far.
+	WagesYTD    tx.USD
+	WithheldYTD tx.USD
+	// Wages and Withheld are projected to the end of the year.
+	Wages    tx.USD
+	Withheld tx.USD
+	// Tax is the tax on the projected wages.
+	Tax tx.USD
+	// SafeHarbor is the least that the withholding and the estimated
+	// payments need to add up to for no underpayment penalty.  SafeHarborRule
+	// says which rule that comes from.
+	SafeHarbor     tx.USD
+	SafeHarborRule string
+	// Short is how much the projected withholding is short of SafeHarbor.
+	Short tx.USD
+	// ExtraPerPayday is the additional withholding on each of the paydays
+	// left that covers Short.
+	ExtraPerPayday tx.USD
+	// Payments are the estimated payments, due after the last paystub, that
+	// cover Short.
+	Payments []Payment
+}
+
+// Projection is the projection of the income taxes of a year.
+type Projection struct {
+	Year int
+	// AsOf is the pay date of the last paystub.
+	AsOf tx.DateOnly
+	// Paydays is the number of paydays left in the year.
+	Paydays int
+	Federal Estimate
+	State   Estimate
+}
+
+// supplemental returns the supplemental wages on the paystub, which are paid
+// on top of the regular pay and are withheld on at flat rates.
+func supplemental(t tx.Transaction) tx.USD {
+	return t.AnnualBonus + t.SpotBonus + t.PeerBonus + t.GoogStockUnit
+}
+
+// installment is the share of the required annual payment due on a day of
+// the year.  The last installment is due in January of the next year.
+type installment struct {
+	month time.Month
+	day   int
+	share float64
+}
+
+var (
+	federalInstallments = []installment{
+		{time.April, 15, 0.25}, {time.June, 15, 0.25}, {time.September, 15, 0.25}, {time.January, 15, 0.25},
+	}
+	// California wants nothing in September.
+	stateInstallments = []installment{
+		{time.April, 15, 0.30}, {time.June, 15, 0.40}, {time.January, 15, 0.30},
+	}
+)
+
+// highIncome is the prior year AGI over which the prior year safe harbor is
+// 110% of the prior year tax, and stateMaxPriorAGI the AGI from which
+// California does not allow the prior year safe harbor at all.
+const (
+	highIncome       = 150000
+	stateMaxPriorAGI = 1000000
+)
+
+// safeHarbor returns the safe harbor for the projected tax, and its rule.
+// A negative prior tax means that the prior year safe harbor does not apply.
+func safeHarbor(tax, prior tx.USD, agi tx.USD) (tx.USD, string) {
+	current := tx.Round(tax * 0.9)
+	if prior < 0 {
+		return current, "90% of the projected tax"
+	}
+	rule := "100% of the prior year tax"
+	if agi > highIncome {
+		prior = tx.Round(prior * 1.1)
+		rule = "110% of the prior year tax"
+	}
+	if prior < current {
+		return prior, rule
+	}
+	return current, "90% of the projected tax"
+}
+
+// payments spreads the amount over the installments due after the date.
+func payments(amount tx.USD, year int, after tx.DateOnly, is []installment) []Payment {
+	var due []time.Time
+	var shares []float64
+	var total float64
+	for _, i := range is {
+		y := year
+		if i.month == time.January {
+			y++
+		}
+		d := time.Date(y, i.month, i.day, 0, 0, 0, 0, time.UTC)
+		if !d.After(time.Time(after)) {
+			continue
+		}
+		due = append(due, d)
+		shares = append(shares, i.share)
+		total += i.share
+	}
+	var r []Payment
+	var paid tx.USD
+	for i, d := range due {
+		a := tx.Round(amount * tx.USD(shares[i]/total))
+		if i == len(due)-1 {
+			// The rounding error goes to the last payment.
+			a = tx.Round(amount - paid)
+		}
+		paid += a
+		r = append(r, Payment{Due: tx.DateOnly(d), Amount: a})
+	}
+	return r
+}
+
+// estimate fills in the tax, the safe harbor and the ways to make up for the
+// shortfall, from the projected wages and withholding.
+func (e *Estimate) estimate(p Projection, s Schedule, prior, agi tx.USD, is []installment) {
+	e.Tax = s.Tax(e.Wages)
+	e.SafeHarbor, e.SafeHarborRule = safeHarbor(e.Tax, prior, agi)
+	if e.Short = tx.Round(e.SafeHarbor - e.Withheld); e.Short <= 0 {
+		e.Short = 0
+		return
+	}
+	if p.Paydays > 0 {
+		e.ExtraPerPayday = tx.Round(e.Short / tx.USD(p.Paydays))
+	}
+	e.Payments = payments(e.Short, p.Year, p.AsOf, is)
+}
+
+// Project projects the wages and the income tax withheld on the paystubs of
+// the year to the end of the year, and compares them with the tax on the
+// projected wages and with the safe harbors.
+//
+// The regular pay goes on for the paydays left in the year, see
+// limits.Paydays, at the amounts of the last regular paystub without
+// supplemental wages.  Supplemental wages, such as bonuses and stock vests,
+// are not projected: they are counted when they are paid.
+func Project(ts []tx.Transaction, year int, o Options) (Projection, error) {
+	p := Projection{Year: year}
+	fs, fy, err := schedule(federal, year, o.Status)
+	if err != nil {
+		return p, fmt.Errorf("federal: %v", err)
+	}
+	ss, sy, err := schedule(state, year, o.Status)
+	if err != nil {
+		return p, fmt.Errorf("state: %v", err)
+	}
+	p.Federal.BracketYear, p.State.BracketYear = fy, sy
+
+	var ys []tx.Transaction
+	for _, t := range ts {
+		if time.Time(t.Date).Year() == year {
+			ys = append(ys, t)
+		}
+	}
+	if len(ys) == 0 {
+		return p, fmt.Errorf("no paystubs in %v", year)
+	}
+	sort.SliceStable(ys, func(i, j int) bool {
+		return time.Time(ys[i].Date).Before(time.Time(ys[j].Date))
+	})
+	var rs []tx.Transaction
+	for _, t := range ys {
+		p.Federal.WagesYTD += t.Wages(tx.TaxFederal)
+		p.Federal.WithheldYTD += t.FederalIncomeTax
+		p.State.WagesYTD += t.Wages(tx.TaxState)
+		p.State.WithheldYTD += t.CAStateIncomeTax
+		if t.Kind == "" && t.RegularPay != 0 {
+			rs = append(rs, t)
+		}
+	}
+	p.AsOf = ys[len(ys)-1].Date
+	p.Paydays = len(limits.Paydays(rs))
+
+	var per tx.Transaction
+	for i := len(rs) - 1; i >= 0; i-- {
+		if per = rs[i]; supplemental(per) == 0 {
+			break
+		}
+	}
+	n := tx.USD(p.Paydays)
+	p.Federal.Wages = tx.Round(p.Federal.WagesYTD + n*per.Wages(tx.TaxFederal))
+	p.Federal.Withheld = tx.Round(p.Federal.WithheldYTD + n*per.FederalIncomeTax)
+	p.State.Wages = tx.Round(p.State.WagesYTD + n*per.Wages(tx.TaxState))
+	p.State.Withheld = tx.Round(p.State.WithheldYTD + n*per.CAStateIncomeTax)
+
+	priorFederal, priorState, agi := tx.USD(-1), tx.USD(-1), tx.USD(0)
+	if o.Prior != nil {
+		priorFederal, agi = o.Prior.FederalTax, o.Prior.AGI
+		if agi < stateMaxPriorAGI {
+			priorState = o.Prior.StateTax
+		}
+	}
+	p.Federal.estimate(p, fs, priorFederal, agi, federalInstallments)
+	p.State.estimate(p, ss, priorState, agi, stateInstallments)
+	return p, nil
+}
@@ -0,0 +1,115 @@
+package withholding
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/filmil/fintools-public/pkg/tx"
+)
+
+// The statutory rates of the flat withholding on supplemental wages.
+const (
+	// FederalSupplementalRate applies to the supplemental wages of the year
+	// up to a million, and FederalSupplementalHighRate, the top bracket rate,
+	// to those over it.
+	FederalSupplementalRate     = 0.22
+	FederalSupplementalHighRate = 0.37
+	// StateBonusRate is the California rate for bonuses and stock options,
+	// which all of the supplemental earnings on the paystub are.  Other
+	// supplemental wages are withheld on at 6.6%.
+	StateBonusRate = 0.1023
+)
+
+// federalHighSupplemental is the amount of the supplemental wages of the year
+// over which FederalSupplementalHighRate applies.
+const federalHighSupplemental = 1000000
+
+// tolerance is how far the withholding on supplemental wages may be from the
+// statutory rate before it is flagged, as a share of the wages.
+const tolerance = 0.005
+
+// split returns the supplemental wages on the paystub t and the tax withheld
+// on them, or false if it can not be told apart from the withholding on the
+// regular pay.  A paystub without regular pay only has supplemental wages.
+// Otherwise the regular part is taken from ref, the last regular paystub
+// before t without supplemental wages, if it has the same regular pay and
+// withholding settings.
+func split(t tx.Transaction, ref *tx.Transaction) (tx.Supplemental, bool) {
+	if t.RegularPay == 0 {
+		return tx.Supplemental{
+			Wages:            t.Wages(tx.TaxFederal),
+			StateWages:       t.Wages(tx.TaxState),
+			FederalIncomeTax: t.FederalIncomeTax,
+			CAStateIncomeTax: t.CAStateIncomeTax,
+		}, true
+	}
+	if ref == nil || ref.RegularPay != t.RegularPay || ref.W4 != t.W4 {
+		return tx.Supplemental{}, false
+	}
+	return tx.Supplemental{
+		Wages:            tx.Round(t.Wages(tx.TaxFederal) - ref.Wages(tx.TaxFederal)),
+		StateWages:       tx.Round(t.Wages(tx.TaxState) - ref.Wages(tx.TaxState)),
+		FederalIncomeTax: tx.Round(t.FederalIncomeTax - ref.FederalIncomeTax),
+		CAStateIncomeTax: tx.Round(t.CAStateIncomeTax - ref.CAStateIncomeTax),
+	}, true
+}
+
+// federalSupplemental returns the statutory federal withholding on the
+// supplemental wages, given the supplemental wages paid earlier in the year.
+func federalSupplemental(wages, before tx.USD) tx.USD {
+	high := math.Max(0, math.Min(float64(wages), float64(before+wages-federalHighSupplemental)))
+	return tx.Round(tx.USD((float64(wages)-high)*FederalSupplementalRate + high*FederalSupplementalHighRate))
+}
+
+// check returns a warning if the withholding is further from the statutory
+// one than the tolerance.
+func check(t tx.Transaction, name string, wages, withheld, statutory tx.USD) string {
+	if wages <= 0 || math.Abs(float64(withheld-statutory)) <= math.Max(1, float64(wages)*tolerance) {
+		return ""
+	}
+	return fmt.Sprintf("%v (%v): %v withholding on supplemental wages of %.2f is %.2f (%.2f%%), want %.2f (%.2f%%) at the statutory rate",
+		time.Time(t.Date).Format("2006-01-02"), t.DocNum, name, wages,
+		withheld, 100*withheld/wages, statutory, 100*statutory/wages)
+}
+
+// Split splits the income tax withholding of the paystubs with supplemental
+// wages between the regular and the supplemental wages where it can, see
+// split, and sets their Supplemental.  It returns warnings for those whose
+// withholding on the supplemental wages is not at the statutory rates.  The
+// transactions should be in date order.  Reversals and corrections are
+// skipped.
+func Split(ts []tx.Transaction) []string {
+	var r []string
+	var ref *tx.Transaction
+	// The supplemental wages so far, by year.
+	before := map[int]tx.USD{}
+	for i := range ts {
+		t := &ts[i]
+		if t.Kind == tx.KindReversal || t.Kind == tx.KindCorrection {
+			continue
+		}
+		if supplemental(*t) == 0 {
+			if t.Kind == "" && t.RegularPay != 0 {
+				ref = t
+			}
+			continue
+		}
+		s, ok := split(*t, ref)
+		if !ok {
+			continue
+		}
+		t.Supplemental = &s
+		y := time.Time(t.Date).Year()
+		for _, w := range []string{
+			check(*t, "federal", s.Wages, s.FederalIncomeTax, federalSupplemental(s.Wages, before[y])),
+			check(*t, "California", s.StateWages, s.CAStateIncomeTax, tx.Round(s.StateWages*StateBonusRate)),
+		} {
+			if w != "" {
+				r = append(r, w)
+			}
+		}
+		before[y] += s.Wages
+	}
+	return r
+}
@@ -0,0 +1,60 @@
+package withholding
+
+import (
+	"testing"
+
+	"github.com/filmil/fintools-public/pkg/tx"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestFederalSupplemental(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		wages, before, expected tx.USD
+	}{
+		{10000, 0, 2200},
+		{10000, 995000, 5000*0.22 + 5000*0.37},
+		{10000, 1000000, 3700},
+	}
+	for _, test := range tests {
+		if actual := federalSupplemental(test.wages, test.before); actual != test.expected {
+			t.Errorf("federalSupplemental(%v, %v)=%v, want: %v", test.wages, test.before, actual, test.expected)
+		}
+	}
+}
+
+func TestSplit(t *testing.T) {
+	t.Parallel()
+	w4 := tx.W4{FederalFilingStatus: "Single"}
+	ts := []tx.Transaction{
+		// The regular paystub that the next one is split by.
+		{Date: date(2020, 3, 13), DocNum: "1", W4: w4, RegularPay: 4000, Bonus401kPre: 400, FederalIncomeTax: 500, CAStateIncomeTax: 200},
+		// A regular paystub with a bonus, withheld on at the statutory rates.
+		{Date: date(2020, 3, 27), DocNum: "2", W4: w4, RegularPay: 4000, Bonus401kPre: 400, AnnualBonus: 10000,
+			FederalIncomeTax: 500 + 2200, CAStateIncomeTax: 200 + 1023},
+		// A bonus-only paystub, under-withheld.
+		{Date: date(2020, 4, 3), DocNum: "3", Kind: tx.KindOffCycle, SpotBonus: 1000, FederalIncomeTax: 150, CAStateIncomeTax: 102.3},
+		// The regular pay changed, so the withholding can not be split.
+		{Date: date(2020, 4, 10), DocNum: "4", W4: w4, RegularPay: 5000, PeerBonus: 100, FederalIncomeTax: 700, CAStateIncomeTax: 300},
+	}
+	expectedWarnings := []string{
+		"2020-04-03 (3): federal withholding on supplemental wages of 1000.00 is 150.00 (15.00%), want 220.00 (22.00%) at the statutory rate",
+	}
+	expected := []*tx.Supplemental{
+		nil,
+		{Wages: 10000, StateWages: 10000, FederalIncomeTax: 2200, CAStateIncomeTax: 1023},
+		{Wages: 1000, StateWages: 1000, FederalIncomeTax: 150, CAStateIncomeTax: 102.3},
+		nil,
+	}
+	actualWarnings := Split(ts)
+	if diff := cmp.Diff(expectedWarnings, actualWarnings); diff != "" {
+		t.Errorf("Split(_)=%v, want: %v\ndiff:\n%v", actualWarnings, expectedWarnings, diff)
+	}
+	var actual []*tx.Supplemental
+	for _, t := range ts {
+		actual = append(actual, t.Supplemental)
+	}
+	if diff := cmp.Diff(expected, actual); diff != "" {
+		t.Errorf("Split(_): Supplemental=%v, want: %v\ndiff:\n%v", actual, expected, diff)
+	}
+}
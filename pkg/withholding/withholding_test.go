@@ -0,0 +1,128 @@
+package withholding
+
+import (
+	"testing"
+	"time"
+
+	"github.com/filmil/fintools-public/pkg/tx"
+	"github.com/google/go-cmp/cmp"
+)
+
+func date(year int, month time.Month, day int) tx.DateOnly {
+	return tx.DateOnly(time.Date(year, month, day, 0, 0, 0, 0, time.UTC))
+}
+
+func TestTax(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name     string
+		table    map[int]map[string]Schedule
+		status   string
+		wages    tx.USD
+		expected tx.USD
+	}{
+		{"federal", federal, StatusSingle, 100000, 13841},
+		{"federal under the deduction", federal, StatusMarried, 20000, 0},
+		{"state", state, StatusSingle, 100000, 5327.14},
+		// The brackets are doubled, and the surcharge is 1% of 100000.
+		{"state over a million", state, StatusMarried, 1111080, 102515.80},
+	}
+	for _, test := range tests {
+		s, _, err := schedule(test.table, 2024, test.status)
+		if err != nil {
+			t.Fatalf("schedule(_, 2024, %q): unexpected error: %v", test.status, err)
+		}
+		if actual := s.Tax(test.wages); actual != test.expected {
+			t.Errorf("%v: Tax(%v)=%v, want: %v", test.name, test.wages, actual, test.expected)
+		}
+	}
+}
+
+func TestScheduleYear(t *testing.T) {
+	t.Parallel()
+	if _, y, err := schedule(state, 2030, StatusSingle); err != nil || y != 2025 {
+		t.Errorf("schedule(state, 2030, _)=%v, %v, want: 2025, nil", y, err)
+	}
+	if _, _, err := schedule(federal, 2000, StatusSingle); err == nil {
+		t.Errorf("schedule(federal, 2000, _): want error")
+	}
+	if _, _, err := schedule(federal, 2024, "widowed"); err == nil {
+		t.Errorf("schedule(federal, 2024, \"widowed\"): want error")
+	}
+}
+
+func TestPayments(t *testing.T) {
+	t.Parallel()
+	expected := []Payment{
+		{date(2024, 4, 15), 300},
+		{date(2024, 6, 15), 400},
+		{date(2025, 1, 15), 300},
+	}
+	actual := payments(1000, 2024, date(2024, 3, 1), stateInstallments)
+	if diff := cmp.Diff(expected, actual, cmp.Comparer(tx.DateOnly.Equal)); diff != "" {
+		t.Errorf("payments(1000, ...)=%v, want: %v\ndiff:\n%v", actual, expected, diff)
+	}
+}
+
+func TestProject(t *testing.T) {
+	t.Parallel()
+	var ts []tx.Transaction
+	// 13 biweekly paystubs until June 28, with 13 paydays left.
+	for d := date(2024, 1, 12); !time.Time(d).After(time.Date(2024, 6, 28, 0, 0, 0, 0, time.UTC)); d = tx.DateOnly(time.Time(d).AddDate(0, 0, 14)) {
+		ts = append(ts, tx.Transaction{
+			Date:             d,
+			RegularPay:       4000,
+			FederalIncomeTax: 400,
+			CAStateIncomeTax: 200,
+		})
+	}
+	ts = append(ts, tx.Transaction{
+		Date:             date(2024, 3, 15),
+		Kind:             tx.KindOffCycle,
+		AnnualBonus:      20000,
+		FederalIncomeTax: 4400,
+		CAStateIncomeTax: 2046,
+	})
+	o := Options{
+		Status: StatusSingle,
+		Prior:  &Prior{FederalTax: 15000, StateTax: 5000, AGI: 160000},
+	}
+	expected := Projection{
+		Year:    2024,
+		AsOf:    date(2024, 6, 28),
+		Paydays: 13,
+		Federal: Estimate{
+			BracketYear:    2024,
+			WagesYTD:       72000,
+			WithheldYTD:    9600,
+			Wages:          124000,
+			Withheld:       14800,
+			Tax:            19298.5,
+			SafeHarbor:     16500,
+			SafeHarborRule: "110% of the prior year tax",
+			Short:          1700,
+			ExtraPerPayday: 130.77,
+			Payments: []Payment{
+				{date(2024, 9, 15), 850},
+				{date(2025, 1, 15), 850},
+			},
+		},
+		State: Estimate{
+			BracketYear:    2024,
+			WagesYTD:       72000,
+			WithheldYTD:    4646,
+			Wages:          124000,
+			Withheld:       7246,
+			Tax:            7559.14,
+			SafeHarbor:     5500,
+			SafeHarborRule: "110% of the prior year tax",
+		},
+	}
+	actual, err := Project(ts, 2024, o)
+	if err != nil {
+		t.Fatalf("Project(_, 2024, %+v): unexpected error: %v", o, err)
+	}
+	if diff := cmp.Diff(expected, actual, cmp.Comparer(tx.DateOnly.Equal)); diff != "" {
+		t.Errorf("Project(_, 2024, %+v)=%+v, want: %+v\ndiff:\n%v", o, actual, expected, diff)
+	}
+}
@@ -126,6 +126,20 @@ func IntersectingBBox(a, b BBox) bool {
 	return r
 }
 
+var _ goxml.MarshalerAttr = BBox{}
+
+// MarshalXMLAttr implements goxml.MarshalerAttr.  It uses the same format as
+// pdf2txt. A null bounding box is omitted.
+func (b BBox) MarshalXMLAttr(name goxml.Name) (goxml.Attr, error) {
+	if b == NullBBox() {
+		return goxml.Attr{}, nil
+	}
+	return goxml.Attr{
+		Name:  name,
+		Value: fmt.Sprintf("%.3f,%.3f,%.3f,%.3f", b.Left, b.Bottom, b.Right, b.Top),
+	}, nil
+}
+
 // UnmarshallerAttr implements goxml.UnmarshallerAttr.
 func (b *BBox) UnmarshalXMLAttr(attr goxml.Attr) error {
 	s := strings.Split(attr.Value, ",")
@@ -0,0 +1,172 @@
+package xml
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// Table cell detection from ruling lines.
+//
+// Many payroll PDFs draw table borders and row separators.  pdf2txt reports
+// those as rects: a thin rect is a horizontal or a vertical rule, and the
+// edges of a larger rect are rules too.  The rules split the page into a grid
+// of cells, and each textline belongs to the cell that contains its center.
+// Finding values by their cell does not depend on how wide the texts are,
+// unlike extending the bounding boxes of the labels.
+
+// ruleTolerance is the largest thickness of a rect that is still considered a
+// rule, and the largest distance between rules that are considered to be the
+// same grid line.  In points.
+const ruleTolerance = 2.0
+
+// Rules returns the x coordinates of the vertical rules and the y coordinates
+// of the horizontal rules formed by the rects that intersect the bounding box
+// b.  The coordinates within ruleTolerance of each other are merged.  The x
+// coordinates are sorted left to right, and the y coordinates top down.
+func Rules(rs []Rect, b BBox) (xs, ys []float64) {
+	for _, r := range rs {
+		rb := r.BBox
+		if rb == NullBBox() || !IntersectingBBox(b, rb) {
+			continue
+		}
+		w, h := rb.Right-rb.Left, rb.Top-rb.Bottom
+		switch {
+		case w <= ruleTolerance && h <= ruleTolerance:
+			// A dot, not a rule.
+		case w <= ruleTolerance:
+			xs = append(xs, (rb.Left+rb.Right)/2)
+		case h <= ruleTolerance:
+			ys = append(ys, (rb.Top+rb.Bottom)/2)
+		default:
+			xs = append(xs, rb.Left, rb.Right)
+			ys = append(ys, rb.Top, rb.Bottom)
+		}
+	}
+	sort.Float64s(xs)
+	sort.Sort(sort.Reverse(sort.Float64Slice(ys)))
+	return mergeClose(xs), mergeClose(ys)
+}
+
+// mergeClose replaces the runs of sorted coordinates that are within
+// ruleTolerance of each other with their average.
+func mergeClose(cs []float64) []float64 {
+	var r []float64
+	for i := 0; i < len(cs); {
+		j, sum := i, 0.0
+		for ; j < len(cs) && math.Abs(cs[j]-cs[i]) <= ruleTolerance; j++ {
+			sum += cs[j]
+		}
+		r = append(r, sum/float64(j-i))
+		i = j
+	}
+	return r
+}
+
+// Grid is a table grid formed by ruling lines.
+type Grid struct {
+	// Xs are the x coordinates of the vertical grid lines, left to right.
+	Xs []float64
+	// Ys are the y coordinates of the horizontal grid lines, top down.
+	Ys []float64
+}
+
+// DetectGrid returns the grid formed by the rules on page p, within the
+// bounding box b.  Use a bounding box around a single table if the page has
+// several tables with different grids.
+func DetectGrid(p Page, b BBox) Grid {
+	xs, ys := Rules(p.Rects, b)
+	return Grid{Xs: xs, Ys: ys}
+}
+
+// Rows returns the number of rows in the grid.
+func (g Grid) Rows() int {
+	if len(g.Ys) < 2 {
+		return 0
+	}
+	return len(g.Ys) - 1
+}
+
+// Cols returns the number of columns in the grid.
+func (g Grid) Cols() int {
+	if len(g.Xs) < 2 {
+		return 0
+	}
+	return len(g.Xs) - 1
+}
+
+// CellBBox returns the bounding box of the cell at the given row and column.
+func (g Grid) CellBBox(row, col int) BBox {
+	return BBox{
+		Left:   g.Xs[col],
+		Right:  g.Xs[col+1],
+		Top:    g.Ys[row],
+		Bottom: g.Ys[row+1],
+	}
+}
+
+// CellOf returns the row and the column of the cell that contains the center
+// of the bounding box b.  Returns false if the center is outside of the grid.
+func (g Grid) CellOf(b BBox) (row, col int, ok bool) {
+	x, y := (b.Left+b.Right)/2, (b.Top+b.Bottom)/2
+	col = sort.Search(len(g.Xs), func(i int) bool { return g.Xs[i] > x }) - 1
+	row = sort.Search(len(g.Ys), func(i int) bool { return g.Ys[i] < y }) - 1
+	if row < 0 || row >= g.Rows() || col < 0 || col >= g.Cols() {
+		return 0, 0, false
+	}
+	return row, col, true
+}
+
+// Cell is a single table cell with the textlines in it.
+type Cell struct {
+	Row, Col  int
+	BBox      BBox
+	Textlines []Textline
+}
+
+// Text returns the text of all textlines in the cell, top down, joined with
+// spaces.
+func (c Cell) Text() string {
+	return strings.Join(TextOf(c.Textlines), " ")
+}
+
+// String implements Stringer.
+func (c Cell) String() string {
+	return fmt.Sprintf("<cell %d,%d %q>", c.Row, c.Col, c.Text())
+}
+
+// Assign returns all cells of the grid, indexed by row and then by column,
+// with each of the textlines assigned to the cell that contains its center.
+// Textlines outside of the grid are dropped.
+func (g Grid) Assign(tls []Textline) [][]Cell {
+	cells := make([][]Cell, g.Rows())
+	for r := range cells {
+		cells[r] = make([]Cell, g.Cols())
+		for c := range cells[r] {
+			cells[r][c] = Cell{Row: r, Col: c, BBox: g.CellBBox(r, c)}
+		}
+	}
+	for _, tl := range SortTop(append([]Textline{}, tls...)) {
+		r, c, ok := g.CellOf(tl.BBox)
+		if !ok {
+			continue
+		}
+		cells[r][c].Textlines = append(cells[r][c].Textlines, tl)
+	}
+	return cells
+}
+
+// ColumnBelow returns the cells in the same column as the header textline,
+// in the rows below it, top down.
+func (g Grid) ColumnBelow(tls []Textline, header Textline) ([]Cell, error) {
+	r, c, ok := g.CellOf(header.BBox)
+	if !ok {
+		return nil, fmt.Errorf("header not in grid: %v", header)
+	}
+	var col []Cell
+	for _, row := range g.Assign(tls)[r+1:] {
+		col = append(col, row[c])
+	}
+	return col, nil
+}
@@ -0,0 +1,83 @@
+package xml
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// table returns the rects of a table with an outer border drawn as a single
+// rect, and thin rules for the inner lines.
+func table() []Rect {
+	return []Rect{
+		// Outer border.
+		{BBox: BBox{Left: 10, Right: 210, Bottom: 10, Top: 100}},
+		// Column separator, a bit off on purpose.
+		{BBox: BBox{Left: 109.5, Right: 110.5, Bottom: 10, Top: 100}},
+		// Row separators.
+		{BBox: BBox{Left: 10, Right: 210, Bottom: 69.8, Top: 70.2}},
+		{BBox: BBox{Left: 10, Right: 210, Bottom: 40, Top: 40.5}},
+		// Not a rule.
+		{BBox: BBox{Left: 300, Right: 301, Bottom: 300, Top: 301}},
+	}
+}
+
+func TestDetectGrid(t *testing.T) {
+	t.Parallel()
+	g := DetectGrid(Page{Rects: table()}, everywhere)
+	expected := Grid{
+		Xs: []float64{10, 110, 210},
+		Ys: []float64{100, 70, 40.25, 10},
+	}
+	if diff := cmp.Diff(expected, g); diff != "" {
+		t.Errorf("DetectGrid(_)=%+v, want: %+v\ndiff:\n%v", g, expected, diff)
+	}
+	if g.Rows() != 3 || g.Cols() != 2 {
+		t.Errorf("Rows()=%v, Cols()=%v, want: 3, 2", g.Rows(), g.Cols())
+	}
+}
+
+func TestAssign(t *testing.T) {
+	t.Parallel()
+	g := DetectGrid(Page{Rects: table()}, everywhere)
+	tls := []Textline{
+		At("Deduction", 15, 80),
+		At("Current", 115, 80),
+		At("Vol Life", 15, 55),
+		At("Spouse", 15, 45),
+		// Wide amount that sticks out of the cell on the left.
+		At("1,234,567.89", 100, 50),
+		At("Medical", 15, 20),
+		At("10.00", 190, 20),
+		// Outside of the grid.
+		At("Net Pay", 300, 300),
+	}
+	cells := g.Assign(tls)
+	var texts [][]string
+	for _, row := range cells {
+		var r []string
+		for _, c := range row {
+			r = append(r, c.Text())
+		}
+		texts = append(texts, r)
+	}
+	expected := [][]string{
+		{"Deduction", "Current"},
+		{"Vol Life Spouse", "1,234,567.89"},
+		{"Medical", "10.00"},
+	}
+	if diff := cmp.Diff(expected, texts); diff != "" {
+		t.Errorf("Assign(_)=%v, want: %v\ndiff:\n%v", texts, expected, diff)
+	}
+
+	col, err := g.ColumnBelow(tls, tls[1])
+	if err != nil {
+		t.Fatalf("ColumnBelow: unexpected error: %v", err)
+	}
+	if len(col) != 2 || col[0].Text() != "1,234,567.89" || col[1].Text() != "10.00" {
+		t.Errorf("ColumnBelow(_)=%v", col)
+	}
+	if _, err := g.ColumnBelow(tls, tls[7]); err == nil {
+		t.Errorf("ColumnBelow(outside)=nil, want error")
+	}
+}
@@ -0,0 +1,192 @@
+package xml
+
+import (
+	"strings"
+	"time"
+
+	"github.com/filmil/fintools-public/pkg/tx"
+	"github.com/golang/glog"
+	"github.com/pkg/errors"
+)
+
+// The labels of the optional header fields.  Paystub formats name the same
+// field differently, so every field has a few alternative labels.
+var (
+	payPeriodLabels      = []string{"Pay Period"}
+	payPeriodStartLabels = []string{"Pay Period Begin", "Pay Period Start", "Period Start", "Period Beginning"}
+	payPeriodEndLabels   = []string{"Pay Period End", "Period End", "Period Ending"}
+	checkDateLabels      = []string{"Check Date"}
+	employeeIDLabels     = []string{"Employee ID", "Employee Number", "Employee #"}
+	payRateLabels        = []string{"Pay Rate", "Annual Salary"}
+	departmentLabels     = []string{"Department"}
+	companyLabels        = []string{"Company"}
+)
+
+// valueRightOf returns the textline that is right of the label and closest
+// to it.
+func valueRightOf(tls []Textline, label Textline) (Textline, error) {
+	r := SortLeft(FindInBBox(tls, label.BBox.RightOf()))
+	if len(r) == 0 {
+		return Textline{}, errors.Errorf("no value right of %q", label.Text())
+	}
+	return r[0], nil
+}
+
+// headerValue returns the text of the value for the first of the labels found
+// in tls.  ok is false if none of the labels is there.
+func headerValue(tls []Textline, labels []string) (value string, ok bool, err error) {
+	for _, l := range labels {
+		tl, err := FindOneTL(tls, l)
+		if err != nil {
+			continue
+		}
+		v, err := valueRightOf(tls, tl)
+		if err != nil {
+			return "", true, err
+		}
+		return v.Text(), true, nil
+	}
+	return "", false, nil
+}
+
+// headerDate is headerValue for dates.
+func headerDate(tls []Textline, labels []string) (tx.DateOnly, error) {
+	v, ok, err := headerValue(tls, labels)
+	if !ok || err != nil {
+		return tx.DateOnly{}, err
+	}
+	return USDate(v)
+}
+
+// parseRate parses a pay rate like "$50.00/hr" or "150,000.00 per year",
+// ignoring the unit.
+func parseRate(s string) (tx.USD, error) {
+	f := strings.Fields(s)
+	if len(f) == 0 {
+		return 0, errors.Errorf("no pay rate in %q", s)
+	}
+	return parseAmount(strings.SplitN(f[0], "/", 2)[0])
+}
+
+// parseHeader fills in the optional header fields of t.  Missing fields are
+// left zero.  So are the fields that are there, but can not be parsed: they
+// are only warned about, since the paystub is still usable without them.
+func parseHeader(tls []Textline, t *tx.Transaction) {
+	// Some paystubs have the pay period as a single "01/01/2020 - 01/15/2020"
+	// value.
+	if v, ok, err := headerValue(tls, payPeriodLabels); err != nil {
+		glog.Warningf("pay period: ignored: %v", err)
+	} else if ok {
+		if start, end, err := payPeriod(v); err != nil {
+			glog.Warningf("pay period: ignored: %v", err)
+		} else {
+			t.PayPeriodStart, t.PayPeriodEnd = start, end
+		}
+	}
+	for _, f := range []struct {
+		name   string
+		labels []string
+		d      *tx.DateOnly
+	}{
+		{"pay period start", payPeriodStartLabels, &t.PayPeriodStart},
+		{"pay period end", payPeriodEndLabels, &t.PayPeriodEnd},
+		{"check date", checkDateLabels, &t.CheckDate},
+	} {
+		if !f.d.IsZero() {
+			continue
+		}
+		d, err := headerDate(tls, f.labels)
+		if err != nil {
+			glog.Warningf("%v: ignored: %v", f.name, err)
+			continue
+		}
+		*f.d = d
+	}
+	if v, ok, err := headerValue(tls, payRateLabels); err != nil {
+		glog.Warningf("pay rate: ignored: %v", err)
+	} else if ok {
+		if r, err := parseRate(v); err != nil {
+			glog.Warningf("pay rate: ignored: %v", err)
+		} else {
+			t.PayRate = r
+		}
+	}
+	for _, f := range []struct {
+		labels []string
+		v      *string
+	}{
+		{employeeIDLabels, &t.EmployeeID},
+		{departmentLabels, &t.Department},
+		{companyLabels, &t.Company},
+	} {
+		v, _, err := headerValue(tls, f.labels)
+		if err != nil {
+			glog.Warningf("%v: ignored: %v", f.labels[0], err)
+			continue
+		}
+		*f.v = v
+	}
+}
+
+// payPeriod parses a pay period like "01/01/2020 - 01/15/2020".
+func payPeriod(v string) (start, end tx.DateOnly, err error) {
+	p := strings.Split(v, "-")
+	if len(p) != 2 {
+		return start, end, errors.Errorf("pay period not a date range: %q", v)
+	}
+	if start, err = USDate(strings.TrimSpace(p[0])); err != nil {
+		return start, end, errors.Wrapf(err, "while parsing pay period start")
+	}
+	if end, err = USDate(strings.TrimSpace(p[1])); err != nil {
+		return tx.DateOnly{}, end, errors.Wrapf(err, "while parsing pay period end")
+	}
+	return start, end, nil
+}
+
+// kindWords maps the words in the paystub titles to the paystub kinds.
+var kindWords = []struct{ word, kind string }{
+	{"reversal", tx.KindReversal},
+	{"void", tx.KindReversal},
+	{"correction", tx.KindCorrection},
+	{"adjustment", tx.KindCorrection},
+	{"off-cycle", tx.KindOffCycle},
+	{"off cycle", tx.KindOffCycle},
+}
+
+// adjustsLabels are the labels of the reference to the adjusted paystub.
+var adjustsLabels = []string{"Original Document", "Adjusts Document", "Reverses Document", "Original Pay Date"}
+
+// parseKind recognizes reversal, correction and off-cycle paystubs by the
+// bold titles in the header box, and finds the paystub they adjust.  It must
+// run after all amounts are parsed.
+func parseKind(tls []Textline, header BBox, t *tx.Transaction) error {
+	for _, tl := range MatchPredicate(FindInBBox(tls, header), BoldText) {
+		s := strings.ToLower(tl.Text())
+		for _, k := range kindWords {
+			if strings.Contains(s, k.word) {
+				t.Kind = k.kind
+				break
+			}
+		}
+		if t.Kind != "" {
+			break
+		}
+	}
+	v, _, err := headerValue(tls, adjustsLabels)
+	if err != nil {
+		return errors.Wrapf(err, "while parsing the adjusted paystub")
+	}
+	if d, err := USDate(v); err == nil {
+		v = time.Time(d).Format("2006-01-02")
+	}
+	t.Adjusts = v
+	if t.Adjusts != "" && t.Kind == "" {
+		t.Kind = tx.KindCorrection
+	}
+	// A reversal takes the money back.  Some paystubs print the reversed
+	// amounts as they were, and only the title tells them apart.
+	if t.Kind == tx.KindReversal {
+		t.Reverse()
+	}
+	return nil
+}
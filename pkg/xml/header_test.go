@@ -0,0 +1,177 @@
+package xml
+
+import (
+	"testing"
+	"time"
+
+	"github.com/filmil/fintools-public/pkg/tx"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseHeader(t *testing.T) {
+	t.Parallel()
+	day := func(m time.Month, d int) tx.DateOnly {
+		return tx.DateOnly(time.Date(2020, m, d, 0, 0, 0, 0, time.UTC))
+	}
+	tests := []struct {
+		name     string
+		tls      []Textline
+		expected tx.Transaction
+	}{
+		{
+			name: "nothing",
+		},
+		{
+			name: "separate",
+			tls: []Textline{
+				At("Period Beginning", 10, 100), At("01/01/2020", 100, 100),
+				At("Period Ending", 10, 90), At("01/15/2020", 100, 90),
+				At("Check Date", 10, 80), At("01/17/2020", 100, 80),
+				At("Employee #", 10, 70), At("000123", 100, 70),
+				// The closest value counts.
+				At("Pay Rate", 10, 60), At("$52.50/hr", 100, 60), At("Net Pay", 200, 60),
+			},
+			expected: tx.Transaction{
+				PayPeriodStart: day(1, 1),
+				PayPeriodEnd:   day(1, 15),
+				CheckDate:      day(1, 17),
+				EmployeeID:     "000123",
+				PayRate:        52.5,
+			},
+		},
+		{
+			name: "range",
+			tls: []Textline{
+				At("Pay Period", 10, 100), At("01/01/2020 - 01/15/2020", 100, 100),
+				At("Annual Salary", 10, 90), At("150,000.00 per year", 100, 90),
+				At("Department", 10, 80), At("Engineering", 100, 80),
+				At("Company", 10, 70), At("Google LLC", 100, 70),
+			},
+			expected: tx.Transaction{
+				PayPeriodStart: day(1, 1),
+				PayPeriodEnd:   day(1, 15),
+				PayRate:        150000,
+				Department:     "Engineering",
+				Company:        "Google LLC",
+			},
+		},
+		{
+			name: "unparsable fields are left zero",
+			tls: []Textline{
+				At("Pay Period", 10, 100), At("January", 100, 100),
+				At("Check Date", 10, 80), At("soon", 100, 80),
+				At("Pay Rate", 10, 70), At("varies", 100, 70),
+				At("Department", 10, 60), At("Engineering", 100, 60),
+			},
+			expected: tx.Transaction{
+				Department: "Engineering",
+			},
+		},
+	}
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			var actual tx.Transaction
+			parseHeader(test.tls, &actual)
+			if diff := cmp.Diff(test.expected, actual, cmp.Comparer(tx.DateOnly.Equal)); diff != "" {
+				t.Errorf("parseHeader(_)=%+v, want: %+v\ndiff:\n%v", actual, test.expected, diff)
+			}
+		})
+	}
+}
+
+func TestParseAmount(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		s        string
+		expected tx.USD
+	}{
+		{"1,234.56", 1234.56},
+		{"$1,234.56", 1234.56},
+		{"(10.00)", -10},
+		{"($10.00)", -10},
+		{"$(10.00)", -10},
+		{"-10.00", -10},
+		{"-$10.00", -10},
+		{"10.00-", -10},
+	}
+	for _, test := range tests {
+		actual, err := parseAmount(test.s)
+		if err != nil {
+			t.Errorf("parseAmount(%q): unexpected error: %v", test.s, err)
+		}
+		if actual != test.expected {
+			t.Errorf("parseAmount(%q)=%v, want: %v", test.s, actual, test.expected)
+		}
+	}
+	if _, err := parseAmount(""); err == nil {
+		t.Errorf("parseAmount(\"\")=nil, want error")
+	}
+}
+
+func TestParseKind(t *testing.T) {
+	t.Parallel()
+	bold := func(tl Textline) Textline {
+		for i := range tl.Texts {
+			tl.Texts[i].Font = "Arial-BoldMT"
+		}
+		return tl
+	}
+	earnings := bold(At("Earnings", 10, 50))
+	tests := []struct {
+		name    string
+		tls     []Textline
+		kind    string
+		adjusts string
+		negated bool
+	}{
+		{
+			name: "regular",
+			tls:  []Textline{bold(At("Pay Statement", 10, 100))},
+		},
+		{
+			name: "not bold",
+			tls:  []Textline{At("Payroll Adjustment Team", 10, 100)},
+		},
+		{
+			name: "below the earnings",
+			tls:  []Textline{bold(At("Reversal", 10, 10))},
+		},
+		{
+			name:    "reversal",
+			tls:     []Textline{bold(At("VOID", 10, 100)), At("Original Document", 10, 90), At("42", 100, 90)},
+			kind:    tx.KindReversal,
+			adjusts: "42",
+			negated: true,
+		},
+		{
+			name:    "correction by date",
+			tls:     []Textline{At("Original Pay Date", 10, 90), At("01/15/2020", 100, 90)},
+			kind:    tx.KindCorrection,
+			adjusts: "2020-01-15",
+		},
+		{
+			name: "off-cycle",
+			tls:  []Textline{bold(At("Off Cycle Payment", 10, 100))},
+			kind: tx.KindOffCycle,
+		},
+	}
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			actual := tx.Transaction{RegularPay: 100, NetPay: 100}
+			if err := parseKind(append(test.tls, earnings), headerBox(&earnings), &actual); err != nil {
+				t.Fatalf("parseKind: unexpected error: %v", err)
+			}
+			if actual.Kind != test.kind || actual.Adjusts != test.adjusts {
+				t.Errorf("parseKind(_)=%q adjusting %q, want: %q adjusting %q",
+					actual.Kind, actual.Adjusts, test.kind, test.adjusts)
+			}
+			if negated := actual.NetPay < 0; negated != test.negated {
+				t.Errorf("parseKind(_) negated=%v, want: %v", negated, test.negated)
+			}
+		})
+	}
+}
@@ -0,0 +1,285 @@
+package xml
+
+import (
+	goxml "encoding/xml"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// hOCR input.
+//
+// hOCR is the HTML based output format of OCR engines such as tesseract, e.g.
+// from `tesseract scan.png out hocr`.  The layout is given by the class
+// attribute of the HTML elements, and the coordinates by their title
+// attribute:
+//
+//	<div class="ocr_page" title="image scan.png; bbox 0 0 2550 3300; scan_res 300 300">
+//	 <div class="ocr_carea" title="bbox 100 120 900 200">
+//	  <span class="ocr_line" title="bbox 100 120 900 150; x_size 30">
+//	   <span class="ocrx_word" title="bbox 100 120 180 150">Pay</span>
+//
+// The coordinates are in pixels with the origin in the top left corner.  They
+// are converted to points (using scan_res if known) with the origin in the
+// bottom left corner, as pdf2txt has them.
+
+// hocrTitle is the parsed title attribute of a hOCR element.
+type hocrTitle map[string][]string
+
+func parseHOCRTitle(s string) hocrTitle {
+	t := hocrTitle{}
+	for _, p := range strings.Split(s, ";") {
+		f := strings.Fields(p)
+		if len(f) == 0 {
+			continue
+		}
+		t[f[0]] = f[1:]
+	}
+	return t
+}
+
+// floats returns the numeric values of the property name.
+func (t hocrTitle) floats(name string) ([]float64, error) {
+	var r []float64
+	for _, v := range t[name] {
+		f, err := strconv.ParseFloat(strings.Trim(v, `"`), 64)
+		if err != nil {
+			return nil, fmt.Errorf("not a number in %v: %q", name, v)
+		}
+		r = append(r, f)
+	}
+	return r, nil
+}
+
+// hocrDecoder keeps the state of a hOCR document being decoded.
+type hocrDecoder struct {
+	p Paystub
+	// height is the page height in pixels, and scale converts pixels to
+	// points.
+	height, scale float64
+	// classes is the stack of the classes of the open elements.
+	classes []string
+	// bold and italic are the numbers of the open <strong> and <em> elements.
+	bold, italic int
+	page         *Page
+	textbox      *Textbox
+	textline     *Textline
+	// size is the font size of the current textline.
+	size float64
+	word *Text
+}
+
+// DecodeHOCR decodes the hOCR document from the reader into Paystub data, so
+// that scanned paystubs can be parsed the same way as the ones from pdf2txt.
+func DecodeHOCR(r io.Reader) (Paystub, error) {
+	var h hocrDecoder
+	d := goxml.NewDecoder(r)
+	d.Strict = false
+	d.AutoClose = goxml.HTMLAutoClose
+	d.Entity = goxml.HTMLEntity
+	for {
+		tok, err := d.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return h.p, errors.Wrapf(err, "while parsing hOCR")
+		}
+		switch t := tok.(type) {
+		case goxml.StartElement:
+			if err := h.start(t); err != nil {
+				return h.p, errors.Wrapf(err, "while parsing hOCR element %v", t.Name.Local)
+			}
+		case goxml.EndElement:
+			h.end(t)
+		case goxml.CharData:
+			if h.word != nil {
+				h.word.T += string(t)
+				if h.bold > 0 || h.italic > 0 {
+					// Remember the font style from the tags around
+					// the word text.
+					h.word.Font = fontStyle(h.bold > 0, h.italic > 0)
+				}
+			}
+		}
+	}
+	if len(h.p.Pages) == 0 {
+		return h.p, errors.Errorf("no ocr_page found in hOCR")
+	}
+	return h.p, nil
+}
+
+func attr(e goxml.StartElement, name string) string {
+	for _, a := range e.Attr {
+		if a.Name.Local == name {
+			return a.Value
+		}
+	}
+	return ""
+}
+
+// bbox returns the bounding box from the title, converted to points with the
+// origin in the lower left corner of the page.
+func (h *hocrDecoder) bbox(t hocrTitle) (BBox, error) {
+	b, err := t.floats("bbox")
+	if err != nil {
+		return BBox{}, err
+	}
+	if len(b) == 0 {
+		return NullBBox(), nil
+	}
+	if len(b) != 4 {
+		return BBox{}, fmt.Errorf("value not a bbox: %v", t["bbox"])
+	}
+	return BBox{
+		Left:   b[0] * h.scale,
+		Top:    (h.height - b[1]) * h.scale,
+		Right:  b[2] * h.scale,
+		Bottom: (h.height - b[3]) * h.scale,
+	}, nil
+}
+
+func (h *hocrDecoder) start(e goxml.StartElement) error {
+	class := attr(e, "class")
+	h.classes = append(h.classes, class)
+	switch e.Name.Local {
+	case "strong", "b":
+		h.bold++
+	case "em", "i":
+		h.italic++
+	}
+	title := parseHOCRTitle(attr(e, "title"))
+	switch class {
+	case "ocr_page":
+		h.scale = 1
+		if res, err := title.floats("scan_res"); err == nil && len(res) > 0 && res[0] > 0 {
+			h.scale = 72 / res[0]
+		}
+		b, err := title.floats("bbox")
+		if err != nil || len(b) != 4 {
+			return fmt.Errorf("page without a bbox: %v", title)
+		}
+		h.height = b[3]
+		h.p.Pages = append(h.p.Pages, Page{
+			ID:   attr(e, "id"),
+			BBox: BBox{Left: b[0] * h.scale, Bottom: 0, Right: b[2] * h.scale, Top: (b[3] - b[1]) * h.scale},
+		})
+		h.page = &h.p.Pages[len(h.p.Pages)-1]
+	case "ocr_carea":
+		if h.page == nil {
+			return fmt.Errorf("content area outside of a page")
+		}
+		b, err := h.bbox(title)
+		if err != nil {
+			return err
+		}
+		h.page.Textboxes = append(h.page.Textboxes, Textbox{ID: len(h.page.Textboxes), BBox: b})
+		h.textbox = &h.page.Textboxes[len(h.page.Textboxes)-1]
+	case "ocr_line", "ocr_header", "ocr_caption", "ocr_textfloat":
+		if h.page == nil {
+			return fmt.Errorf("line outside of a page")
+		}
+		b, err := h.bbox(title)
+		if err != nil {
+			return err
+		}
+		if h.textbox == nil {
+			// Not all engines output content areas.
+			h.page.Textboxes = append(h.page.Textboxes, Textbox{ID: len(h.page.Textboxes), BBox: b})
+			h.textbox = &h.page.Textboxes[len(h.page.Textboxes)-1]
+		}
+		h.size = 0
+		if s, err := title.floats("x_size"); err == nil && len(s) > 0 {
+			h.size = s[0] * h.scale
+		}
+		h.textbox.Textlines = append(h.textbox.Textlines, Textline{BBox: b})
+		h.textline = &h.textbox.Textlines[len(h.textbox.Textlines)-1]
+	case "ocrx_word":
+		if h.textline == nil {
+			return fmt.Errorf("word outside of a line")
+		}
+		b, err := h.bbox(title)
+		if err != nil {
+			return err
+		}
+		h.word = &Text{BBox: b, Size: h.size}
+	}
+	return nil
+}
+
+func (h *hocrDecoder) end(e goxml.EndElement) {
+	switch e.Name.Local {
+	case "strong", "b":
+		h.bold--
+	case "em", "i":
+		h.italic--
+	}
+	if len(h.classes) == 0 {
+		return
+	}
+	class := h.classes[len(h.classes)-1]
+	h.classes = h.classes[:len(h.classes)-1]
+	switch class {
+	case "ocr_page":
+		h.page, h.textbox, h.textline = nil, nil, nil
+	case "ocr_carea":
+		h.textbox, h.textline = nil, nil
+	case "ocr_line", "ocr_header", "ocr_caption", "ocr_textfloat":
+		if h.textline != nil {
+			h.textline.Texts = append(h.textline.Texts, Text{T: "\n"})
+			if h.textline.BBox == NullBBox() {
+				h.textline.BBox = wordsBBox(h.textline.Texts)
+			}
+		}
+		h.textline = nil
+	case "ocrx_word":
+		w := *h.word
+		h.word = nil
+		w.T = strings.TrimSpace(w.T)
+		if w.T == "" {
+			return
+		}
+		if len(h.textline.Texts) > 0 {
+			h.textline.Texts = append(h.textline.Texts, Text{T: " "})
+		}
+		h.textline.Texts = append(h.textline.Texts, w)
+	}
+}
+
+// fontStyle returns a font name that Textline.Bold and Textline.Italic
+// recognize.  OCR engines do not know the real font name.
+func fontStyle(bold, italic bool) string {
+	switch {
+	case bold && italic:
+		return "OCR-BoldItalic"
+	case bold:
+		return "OCR-Bold"
+	case italic:
+		return "OCR-Italic"
+	}
+	return ""
+}
+
+// wordsBBox returns the bounding box around all texts that have one.
+func wordsBBox(ts []Text) BBox {
+	var r BBox
+	for _, t := range ts {
+		b := t.BBox
+		if b == NullBBox() {
+			continue
+		}
+		if r == NullBBox() {
+			r = b
+			continue
+		}
+		r.Left = math.Min(r.Left, b.Left)
+		r.Right = math.Max(r.Right, b.Right)
+		r.Bottom = math.Min(r.Bottom, b.Bottom)
+		r.Top = math.Max(r.Top, b.Top)
+	}
+	return r
+}
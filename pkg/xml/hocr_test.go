@@ -0,0 +1,94 @@
+package xml
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+const hocr = `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE html PUBLIC "-//W3C//DTD XHTML 1.0 Transitional//EN"
+    "http://www.w3.org/TR/xhtml1/DTD/xhtml1-transitional.dtd">
+<html xmlns="http://www.w3.org/1999/xhtml" xml:lang="en" lang="en">
+ <head>
+  <title></title>
+  <meta http-equiv="Content-Type" content="text/html;charset=utf-8"/>
+  <meta name='ocr-system' content='tesseract 5.3.0' />
+ </head>
+ <body>
+  <div class='ocr_page' id='page_1' title='image "scan.png"; bbox 0 0 2550 3300; ppageno 0; scan_res 300 300'>
+   <div class='ocr_carea' id='block_1_1' title="bbox 300 300 1500 400">
+    <p class='ocr_par' id='par_1_1' lang='eng' title="bbox 300 300 1500 400">
+     <span class='ocr_line' id='line_1_1' title="bbox 300 300 600 400; baseline 0 -10; x_size 50; x_descenders 10; x_ascenders 12">
+      <span class='ocrx_word' id='word_1_1' title='bbox 300 300 400 400; x_wconf 96'><strong>Pay</strong></span>
+      <span class='ocrx_word' id='word_1_2' title='bbox 420 300 600 400; x_wconf 95'><strong>Date</strong></span>
+     </span>
+     <span class='ocr_line' id='line_1_2' title="bbox 900 300 1500 400; x_size 50">
+      <span class='ocrx_word' id='word_1_3' title='bbox 900 300 1500 400; x_wconf 91'>01/18/2019</span>
+     </span>
+    </p>
+   </div>
+   <div class='ocr_carea' id='block_1_2' title="bbox 300 600 800 700">
+    <p class='ocr_par' id='par_1_2' lang='eng'>
+     <span class='ocr_line' id='line_1_3'>
+      <span class='ocrx_word' id='word_1_4' title='bbox 300 600 500 700'>AT&amp;T</span>
+      <span class='ocrx_word' id='word_1_5' title='bbox 520 600 800 700'><em>Inc</em></span>
+     </span>
+    </p>
+   </div>
+  </div>
+ </body>
+</html>
+`
+
+func TestDecodeHOCR(t *testing.T) {
+	t.Parallel()
+	p, err := DecodeHOCR(strings.NewReader(hocr))
+	if err != nil {
+		t.Fatalf("DecodeHOCR: unexpected error: %v", err)
+	}
+	if len(p.Pages) != 1 {
+		t.Fatalf("DecodeHOCR(_)=%+v, want 1 page", p)
+	}
+	pg := p.Pages[0]
+	if diff := cmp.Diff(BBox{Left: 0, Bottom: 0, Right: 612, Top: 792}, pg.BBox); diff != "" {
+		t.Errorf("page bbox diff:\n%v", diff)
+	}
+	tls := Textlines(pg)
+	if diff := cmp.Diff([]string{"Pay Date", "01/18/2019", "AT&T Inc"}, TextOf(tls)); diff != "" {
+		t.Errorf("texts diff:\n%v", diff)
+	}
+	// 300 dpi pixels to points, flipped.
+	expected := BBox{Left: 72, Right: 144, Top: 720, Bottom: 696}
+	if diff := cmp.Diff(expected, tls[0].BBox); diff != "" {
+		t.Errorf("textline bbox diff:\n%v", diff)
+	}
+	if !tls[0].Bold() || tls[1].Bold() {
+		t.Errorf("Bold()=%v,%v, want: true,false", tls[0].Bold(), tls[1].Bold())
+	}
+	if tls[0].Size() != 12 {
+		t.Errorf("Size()=%v, want: 12", tls[0].Size())
+	}
+	// Line without a bbox gets one from its words.
+	if diff := cmp.Diff(BBox{Left: 72, Right: 192, Top: 648, Bottom: 624}, tls[2].BBox); diff != "" {
+		t.Errorf("computed textline bbox diff:\n%v", diff)
+	}
+
+	// The queries work the same as on pdf2txt output.
+	payDate, err := FindOneTL(tls, "Pay Date")
+	if err != nil {
+		t.Fatalf("FindOneTL: unexpected error: %v", err)
+	}
+	date, err := OneTextline(FindInBBox(tls, payDate.BBox.RightOf()))
+	if err != nil || date.Text() != "01/18/2019" {
+		t.Errorf("date right of Pay Date: %v, %v", date, err)
+	}
+}
+
+func TestDecodeHOCRNoPage(t *testing.T) {
+	t.Parallel()
+	if _, err := DecodeHOCR(strings.NewReader("<html><body></body></html>")); err == nil {
+		t.Errorf("DecodeHOCR(empty)=nil, want error")
+	}
+}
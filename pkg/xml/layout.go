@@ -105,7 +105,7 @@ func (r Rect) ForAllBBox(do func(BBox)) {
 }
 
 type Figure struct {
-	Name  string  `xml:"name,attr,omitempty"'`
+	Name  string  `xml:"name,attr,omitempty"`
 	BBox  BBox    `xml:"bbox,attr,omitempty"`
 	Image []Image `xml:"image,omitempty"`
 }
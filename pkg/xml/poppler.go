@@ -0,0 +1,110 @@
+package xml
+
+import (
+	goxml "encoding/xml"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// poppler input.
+//
+// `pdftotext -bbox-layout paystub.pdf paystub.html` from poppler-utils writes
+// an XHTML file with the bounding boxes of the pages, blocks, lines and words:
+//
+//	<doc>
+//	 <page width="612.000000" height="792.000000">
+//	  <flow>
+//	   <block xMin="40.0" yMin="42.0" xMax="100.0" yMax="52.0">
+//	    <line xMin="40.0" yMin="42.0" xMax="100.0" yMax="52.0">
+//	     <word xMin="40.0" yMin="42.0" xMax="58.0" yMax="52.0">Pay</word>
+//
+// The coordinates are in points, with the origin in the top left corner.
+// They are flipped to have the origin in the bottom left corner, as pdf2txt
+// has them.
+
+type popplerBox struct {
+	XMin float64 `xml:"xMin,attr"`
+	YMin float64 `xml:"yMin,attr"`
+	XMax float64 `xml:"xMax,attr"`
+	YMax float64 `xml:"yMax,attr"`
+}
+
+// bbox returns the box as a BBox on a page of the given height.
+func (b popplerBox) bbox(height float64) BBox {
+	return BBox{
+		Left:   b.XMin,
+		Right:  b.XMax,
+		Top:    height - b.YMin,
+		Bottom: height - b.YMax,
+	}
+}
+
+type popplerWord struct {
+	popplerBox
+	T string `xml:",chardata"`
+}
+
+type popplerLine struct {
+	popplerBox
+	Words []popplerWord `xml:"word"`
+}
+
+type popplerBlock struct {
+	popplerBox
+	Lines []popplerLine `xml:"line"`
+}
+
+type popplerPage struct {
+	Width  float64        `xml:"width,attr"`
+	Height float64        `xml:"height,attr"`
+	Blocks []popplerBlock `xml:"flow>block"`
+}
+
+type popplerDoc struct {
+	Pages []popplerPage `xml:"body>doc>page"`
+}
+
+// DecodePoppler decodes the output of `pdftotext -bbox-layout` from the reader
+// into Paystub data.  Blocks become textboxes, and lines become textlines with
+// one text per word.
+func DecodePoppler(r io.Reader) (Paystub, error) {
+	var p Paystub
+	var doc popplerDoc
+	d := goxml.NewDecoder(r)
+	d.Entity = goxml.HTMLEntity
+	if err := d.Decode(&doc); err != nil {
+		return p, errors.Wrapf(err, "while parsing poppler XHTML")
+	}
+	if len(doc.Pages) == 0 {
+		return p, errors.Errorf("no pages found, was pdftotext run with -bbox-layout?")
+	}
+	for i, pp := range doc.Pages {
+		pg := Page{
+			ID:   strconv.Itoa(i + 1),
+			BBox: BBox{Left: 0, Bottom: 0, Right: pp.Width, Top: pp.Height},
+		}
+		for _, b := range pp.Blocks {
+			tb := Textbox{ID: len(pg.Textboxes), BBox: b.bbox(pp.Height)}
+			for _, l := range b.Lines {
+				tl := Textline{BBox: l.bbox(pp.Height)}
+				for _, w := range l.Words {
+					if len(tl.Texts) > 0 {
+						tl.Texts = append(tl.Texts, Text{T: " "})
+					}
+					tl.Texts = append(tl.Texts, Text{
+						BBox: w.bbox(pp.Height),
+						T:    strings.TrimSpace(w.T),
+					})
+				}
+				tl.Texts = append(tl.Texts, Text{T: "\n"})
+				tb.Textlines = append(tb.Textlines, tl)
+			}
+			pg.Textboxes = append(pg.Textboxes, tb)
+		}
+		p.Pages = append(p.Pages, pg)
+	}
+	return p, nil
+}
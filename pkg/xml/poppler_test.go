@@ -0,0 +1,79 @@
+package xml
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+const poppler = `<!DOCTYPE html PUBLIC "-//W3C//DTD XHTML 1.0 Transitional//EN" "http://www.w3.org/TR/xhtml1/DTD/xhtml1-transitional.dtd">
+<html xmlns="http://www.w3.org/1999/xhtml">
+<head>
+<title></title>
+<meta name="Producer" content="Skia/PDF m100"/>
+</head>
+<body>
+<doc>
+  <page width="612.000000" height="792.000000">
+    <flow>
+      <block xMin="40.000000" yMin="50.000000" xMax="200.000000" yMax="60.000000">
+        <line xMin="40.000000" yMin="50.000000" xMax="80.000000" yMax="60.000000">
+          <word xMin="40.000000" yMin="50.000000" xMax="56.000000" yMax="60.000000">Pay</word>
+          <word xMin="58.000000" yMin="50.000000" xMax="80.000000" yMax="60.000000">Date</word>
+        </line>
+        <line xMin="120.000000" yMin="50.000000" xMax="200.000000" yMax="60.000000">
+          <word xMin="120.000000" yMin="50.000000" xMax="200.000000" yMax="60.000000">01/18/2019</word>
+        </line>
+      </block>
+    </flow>
+  </page>
+  <page width="612.000000" height="792.000000">
+    <flow>
+      <block xMin="40.000000" yMin="50.000000" xMax="80.000000" yMax="60.000000">
+        <line xMin="40.000000" yMin="50.000000" xMax="80.000000" yMax="60.000000">
+          <word xMin="40.000000" yMin="50.000000" xMax="80.000000" yMax="60.000000">AT&amp;T</word>
+        </line>
+      </block>
+    </flow>
+  </page>
+</doc>
+</body>
+</html>
+`
+
+func TestDecodePoppler(t *testing.T) {
+	t.Parallel()
+	p, err := DecodeFormat(strings.NewReader(poppler), FormatPoppler)
+	if err != nil {
+		t.Fatalf("DecodePoppler: unexpected error: %v", err)
+	}
+	if len(p.Pages) != 2 {
+		t.Fatalf("DecodePoppler(_)=%+v, want 2 pages", p)
+	}
+	tls := Textlines(p.Pages[0])
+	if diff := cmp.Diff([]string{"Pay Date", "01/18/2019"}, TextOf(tls)); diff != "" {
+		t.Errorf("texts diff:\n%v", diff)
+	}
+	if diff := cmp.Diff(BBox{Left: 40, Right: 80, Top: 742, Bottom: 732}, tls[0].BBox); diff != "" {
+		t.Errorf("textline bbox diff:\n%v", diff)
+	}
+	payDate, err := FindOneTL(tls, "Pay Date")
+	if err != nil {
+		t.Fatalf("FindOneTL: unexpected error: %v", err)
+	}
+	date, err := OneTextline(FindInBBox(tls, payDate.BBox.RightOf()))
+	if err != nil || date.Text() != "01/18/2019" {
+		t.Errorf("date right of Pay Date: %v, %v", date, err)
+	}
+	if diff := cmp.Diff([]string{"AT&T"}, TextOf(Textlines(p.Pages[1]))); diff != "" {
+		t.Errorf("page 2 texts diff:\n%v", diff)
+	}
+}
+
+func TestDecodeFormatUnknown(t *testing.T) {
+	t.Parallel()
+	if _, err := DecodeFormat(strings.NewReader(poppler), "pdf"); err == nil {
+		t.Errorf("DecodeFormat(_, %q)=nil, want error", "pdf")
+	}
+}
@@ -50,6 +50,15 @@ func MatchPredicate(
 	return t
 }
 
+// Containing returns a predicate for the textlines containing the text, in
+// any case.
+func Containing(text string) Predicate {
+	text = strings.ToLower(text)
+	return func(t Textline) bool {
+		return strings.Contains(strings.ToLower(t.Text()), text)
+	}
+}
+
 // TextOf returns the texts corresponding to the given textlines.
 func TextOf(ts []Textline) []string {
 	var s []string
@@ -102,6 +111,34 @@ func MatchingSuffix(prefix string, t Textline) bool {
 	return strings.HasSuffix(t.Text(), prefix)
 }
 
+// BoldText returns true if the textline is set in a bold font.  It can be
+// used as a Predicate.
+func BoldText(t Textline) bool {
+	return t.Bold()
+}
+
+// ItalicText returns true if the textline is set in an italic font.  It can be
+// used as a Predicate.
+func ItalicText(t Textline) bool {
+	return t.Italic()
+}
+
+// FontSizeAtLeast returns a Predicate which matches textlines with the font
+// size of at least size.
+func FontSizeAtLeast(size float64) Predicate {
+	return func(t Textline) bool {
+		return t.Size() >= size
+	}
+}
+
+// FontNamed returns a Predicate which matches textlines with the dominant font
+// name containing name, e.g. "Arial".
+func FontNamed(name string) Predicate {
+	return func(t Textline) bool {
+		return strings.Contains(t.Font(), name)
+	}
+}
+
 /// Below functions build on the primitives to get the more commonly useful
 /// functionality.
 
@@ -133,6 +170,21 @@ func FindOneTLInBBoxWithSuffix(tls []Textline, suffix string, bbox BBox) (Textli
 			BindBBox(bbox, IntersectingBBoxTextline)))
 }
 
+// FindOneHeaderInBBox finds the (known) single textline containing exactly
+// text, within the extents of the given bounding box, like FindOneTLInBBox.
+// If several textlines match, only the ones set in bold are considered, since
+// section headers usually are.  This tells the header "Taxes" apart from a
+// line item with the same text.
+func FindOneHeaderInBBox(tls []Textline, text string, bbox BBox) (Textline, error) {
+	m := MatchPredicate(tls,
+		BindText(text, MatchingText),
+		BindBBox(bbox, IntersectingBBoxTextline))
+	if len(m) > 1 {
+		m = MatchPredicate(m, BoldText)
+	}
+	return OneTextline(m)
+}
+
 // FindInBBox filters textlines to only those that intersect with bbox.
 func FindInBBox(tls []Textline, bbox BBox) []Textline {
 	return MatchPredicate(tls, BindBBox(bbox, IntersectingBBoxTextline))
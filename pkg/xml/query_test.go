@@ -78,3 +78,62 @@ func TestIntersectingBBox(t *testing.T) {
 			})
 	}
 }
+
+func textline(text, font string, size float64) Textline {
+	var tl Textline
+	for _, c := range text {
+		tl.Texts = append(tl.Texts, Text{T: string(c), Font: font, Size: size})
+	}
+	return tl
+}
+
+func TestFontPredicates(t *testing.T) {
+	t.Parallel()
+	mixed := textline("Tax", "Arial-BoldMT", 10)
+	mixed.Texts = append(mixed.Texts, textline("es", "ArialMT", 8).Texts...)
+	tls := []Textline{
+		textline("Taxes", "Arial-BoldMT", 10),
+		textline("Taxes", "ArialMT", 8),
+		textline("Taxes", "Helvetica-Oblique", 8),
+		mixed,
+	}
+	tests := []struct {
+		name      string
+		predicate Predicate
+		expected  []int
+	}{
+		{"bold", BoldText, []int{0, 3}},
+		{"italic", ItalicText, []int{2}},
+		{"size", FontSizeAtLeast(9), []int{0, 3}},
+		{"font", FontNamed("Arial"), []int{0, 1, 3}},
+	}
+	for _, test := range tests {
+		var actual []int
+		for i, tl := range tls {
+			if len(MatchPredicate([]Textline{tl}, test.predicate)) == 1 {
+				actual = append(actual, i)
+			}
+		}
+		if fmt.Sprint(actual) != fmt.Sprint(test.expected) {
+			t.Errorf("%v: matched %v, want: %v", test.name, actual, test.expected)
+		}
+	}
+	if f := mixed.Font(); f != "Arial-BoldMT" {
+		t.Errorf("Font()=%q, want: %q", f, "Arial-BoldMT")
+	}
+}
+
+func TestFindOneHeaderInBBox(t *testing.T) {
+	t.Parallel()
+	tls := []Textline{
+		textline("Taxes", "ArialMT", 8),
+		textline("Taxes", "Arial-BoldMT", 10),
+	}
+	tl, err := FindOneHeaderInBBox(tls, "Taxes", everywhere)
+	if err != nil {
+		t.Fatalf("FindOneHeaderInBBox: unexpected error: %v", err)
+	}
+	if !tl.Bold() {
+		t.Errorf("FindOneHeaderInBBox(_)=%v, want the bold one", tl)
+	}
+}
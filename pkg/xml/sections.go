@@ -0,0 +1,73 @@
+package xml
+
+import (
+	"math"
+	"sort"
+
+	"github.com/filmil/fintools-public/pkg/tx"
+)
+
+// Paystub sections.
+//
+// Not every paystub has every section: bonus-only or vest-only paystubs may
+// leave out the deductions, the taxes, or even the earnings.  So the section
+// headers are all optional, and each section extends from its header to the
+// right and down, until the next header in that direction.
+
+// otherHeaders are the headers of the parts of a paystub that are not
+// parsed, but that end the sections above them.
+var otherHeaders = []string{"Tax Withholding Information", "Net Pay Distribution", "Stock Vest Details"}
+
+// findHeader finds the section header with the text in the bounding box.  It
+// returns nil if there is none.
+func findHeader(tls []Textline, text string, bbox BBox) (*Textline, error) {
+	m := MatchPredicate(tls,
+		BindText(text, MatchingText),
+		BindBBox(bbox, IntersectingBBoxTextline))
+	if len(m) == 0 {
+		return nil, nil
+	}
+	tl, err := FindOneHeaderInBBox(m, text, bbox)
+	if err != nil {
+		return nil, err
+	}
+	return &tl, nil
+}
+
+// sectionBox returns the box of the section with the header h.  It extends
+// right until the closest of the other headers on the same row, and down
+// until the closest of the other headers below it.
+func sectionBox(h *Textline, others ...*Textline) BBox {
+	b := h.BBox.ExtendRight().ExtendBottom()
+	row := h.BBox.RightOf()
+	for _, o := range others {
+		if o == nil || o == h {
+			continue
+		}
+		if IntersectingBBox(row, o.BBox) {
+			b.Right = math.Min(b.Right, o.BBox.Left)
+			continue
+		}
+		if o.BBox.Top < h.BBox.Bottom {
+			b.Bottom = math.Max(b.Bottom, o.BBox.Top+eps)
+		}
+	}
+	return b
+}
+
+// headerBox returns the box above all the section headers, which has the
+// paystub title and the header fields.
+func headerBox(headers ...*Textline) BBox {
+	b := everywhere
+	for _, h := range headers {
+		if h != nil && h.BBox.Top > b.Bottom {
+			b.Bottom = h.BBox.Top
+		}
+	}
+	return b
+}
+
+// sortSections sorts the section names in the order of tx.Sections.
+func sortSections(ss []string) {
+	sort.SliceStable(ss, func(i, j int) bool { return tx.SectionIndex(ss[i]) < tx.SectionIndex(ss[j]) })
+}
@@ -0,0 +1,46 @@
+package xml
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestSectionBox(t *testing.T) {
+	t.Parallel()
+	earnings := At("Earnings", 10, 100)
+	deductions := At("Deductions", 200, 100)
+	taxes := At("Taxes", 10, 50)
+	pto := At("Paid Time Off", 10, 20)
+
+	tests := []struct {
+		name     string
+		h        *Textline
+		others   []*Textline
+		expected BBox
+	}{
+		{
+			name:     "all",
+			h:        &earnings,
+			others:   []*Textline{&earnings, &deductions, &taxes, &pto},
+			expected: BBox{Left: 10, Right: 200, Top: 108, Bottom: 58 + eps},
+		},
+		{
+			name:     "no taxes",
+			h:        &deductions,
+			others:   []*Textline{&earnings, nil, &pto},
+			expected: BBox{Left: 200, Right: everywhere.Right, Top: 108, Bottom: 28 + eps},
+		},
+		{
+			name:     "alone",
+			h:        &taxes,
+			expected: BBox{Left: 10, Right: everywhere.Right, Top: 58, Bottom: everywhere.Bottom},
+		},
+	}
+	for _, test := range tests {
+		actual := sectionBox(test.h, test.others...)
+		if diff := cmp.Diff(test.expected, actual); diff != "" {
+			t.Errorf("%v: sectionBox(_)=%v, want: %v\ndiff:\n%v", test.name, actual, test.expected, diff)
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package xml
+
+import (
+	goxml "encoding/xml"
+	"io"
+
+	"github.com/filmil/fintools-public/pkg/tx"
+	"github.com/pkg/errors"
+)
+
+// StreamOptions modify the behavior of DecodeStream.
+type StreamOptions struct {
+	// DropTexts, if set, replaces the per-character Text elements of each
+	// textline with a single Text holding the entire line.  This saves a lot
+	// of memory on large documents.
+	DropTexts bool
+	// DropLayout, if set, discards the layout analysis (textgroups) of each
+	// page.  Nothing in this package needs it for parsing.
+	DropLayout bool
+}
+
+// ErrStop can be returned from the DecodeStream callback to stop decoding
+// early. DecodeStream then returns nil.
+var ErrStop = errors.New("stop decoding")
+
+// DecodeStream decodes the reader one page at a time, calling do on each
+// decoded page in document order.  Only one page is kept in memory at a time.
+// As with Decode, the coordinates of rotated pages are those of the page as
+// shown.
+func DecodeStream(r io.Reader, o StreamOptions, do func(p Page) error) error {
+	d := goxml.NewDecoder(r)
+	for {
+		tok, err := d.Token()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return errors.Wrapf(err, "while parsing XML")
+		}
+		se, ok := tok.(goxml.StartElement)
+		if !ok || se.Name.Local != "page" {
+			continue
+		}
+		var p Page
+		if err := d.DecodeElement(&p, &se); err != nil {
+			return errors.Wrapf(err, "while parsing page")
+		}
+		if o.DropTexts {
+			p = DropTexts(p)
+		}
+		if o.DropLayout {
+			p.Layout = Layout{}
+		}
+		if err := do(p); err != nil {
+			if err == ErrStop {
+				return nil
+			}
+			return errors.Wrapf(err, "while processing page %q", p.ID)
+		}
+	}
+}
+
+// DecodeFirstPage decodes only the first page from the reader, and stops
+// reading there.  The returned Paystub has a single page.
+func DecodeFirstPage(r io.Reader, o StreamOptions) (Paystub, error) {
+	var p Paystub
+	if err := DecodeStream(r, o, func(pg Page) error {
+		p.Pages = append(p.Pages, pg)
+		return ErrStop
+	}); err != nil {
+		return p, err
+	}
+	if len(p.Pages) == 0 {
+		return p, errors.Errorf("no pages found")
+	}
+	return p, nil
+}
+
+// DropTexts returns a copy of the page in which the per-character Text
+// elements of every textline are replaced by a single Text spanning the
+// textline.  The textline text, dominant font and size are unchanged.
+func DropTexts(p Page) Page {
+	tbs := make([]Textbox, len(p.Textboxes))
+	for i, tb := range p.Textboxes {
+		tls := make([]Textline, len(tb.Textlines))
+		for j, tl := range tb.Textlines {
+			tls[j] = dropTexts(tl)
+		}
+		tb.Textlines = tls
+		tbs[i] = tb
+	}
+	p.Textboxes = tbs
+	return p
+}
+
+func dropTexts(tl Textline) Textline {
+	tl.Texts = []Text{{BBox: tl.BBox, T: tl.Text(), Font: tl.Font(), Size: tl.Size()}}
+	return tl
+}
+
+// ParseStream parses the first page of the passed reader into a Transaction
+// like ParseWithOptions does, but without decoding the rest of the document.
+func ParseStream(r io.Reader, o Options) (tx.Transaction, error) {
+	var t tx.Transaction
+	p, err := DecodeFirstPage(r, StreamOptions{DropTexts: true, DropLayout: true})
+	if err != nil {
+		return t, errors.Wrapf(err, "while decoding input to Paystub")
+	}
+	t, err = ConvertWithOptions(p, o)
+	if err != nil {
+		return t, errors.Wrapf(err, "while converting Paystub to Transaction")
+	}
+	return t, nil
+}
@@ -0,0 +1,162 @@
+package xml
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+const twoPages = `<?xml version="1.0" encoding="utf-8" ?>
+<pages>
+<page id="1" bbox="0.000,0.000,612.000,792.000" rotate="0">
+<textbox id="0" bbox="10.000,700.000,40.000,710.000">
+<textline bbox="10.000,700.000,40.000,710.000">
+<text font="Arial-BoldMT" bbox="10.000,700.000,20.000,710.000" size="10.000">P</text>
+<text font="Arial-BoldMT" bbox="20.000,700.000,30.000,710.000" size="10.000">a</text>
+<text font="Arial-BoldMT" bbox="30.000,700.000,40.000,710.000" size="10.000">y</text>
+<text>
+</text>
+</textline>
+</textbox>
+<layout>
+<textgroup bbox="10.000,700.000,40.000,710.000">
+<textbox id="0" bbox="10.000,700.000,40.000,710.000" />
+</textgroup>
+</layout>
+</page>
+<page id="2" bbox="0.000,0.000,612.000,792.000" rotate="0">
+<textbox id="0" bbox="10.000,700.000,30.000,710.000">
+<textline bbox="10.000,700.000,30.000,710.000">
+<text font="ArialMT" bbox="10.000,700.000,20.000,710.000" size="9.000">T</text>
+<text font="ArialMT" bbox="20.000,700.000,30.000,710.000" size="9.000">o</text>
+<text>
+</text>
+</textline>
+</textbox>
+</page>
+</pages>
+`
+
+func TestDecodeStream(t *testing.T) {
+	t.Parallel()
+	var ids, texts []string
+	err := DecodeStream(strings.NewReader(twoPages), StreamOptions{DropTexts: true, DropLayout: true},
+		func(p Page) error {
+			ids = append(ids, p.ID)
+			tls := Textlines(p)
+			texts = append(texts, TextOf(tls)...)
+			for _, tl := range tls {
+				if len(tl.Texts) != 1 {
+					t.Errorf("len(Texts)=%v, want 1: %+v", len(tl.Texts), tl.Texts)
+				}
+			}
+			if len(p.Layout.Textgroups) != 0 {
+				t.Errorf("layout not dropped: %+v", p.Layout)
+			}
+			return nil
+		})
+	if err != nil {
+		t.Fatalf("DecodeStream: unexpected error: %v", err)
+	}
+	if diff := cmp.Diff([]string{"1", "2"}, ids); diff != "" {
+		t.Errorf("page ids diff: %v", diff)
+	}
+	if diff := cmp.Diff([]string{"Pay", "To"}, texts); diff != "" {
+		t.Errorf("texts diff: %v", diff)
+	}
+}
+
+func TestDecodeStreamStop(t *testing.T) {
+	t.Parallel()
+	p, err := DecodeFirstPage(strings.NewReader(twoPages), StreamOptions{})
+	if err != nil {
+		t.Fatalf("DecodeFirstPage: unexpected error: %v", err)
+	}
+	if len(p.Pages) != 1 || p.Pages[0].ID != "1" {
+		t.Errorf("DecodeFirstPage(_)=%+v, want only page 1", p)
+	}
+	if n := len(p.Pages[0].Textboxes[0].Textlines[0].Texts); n != 4 {
+		t.Errorf("len(Texts)=%v, want 4", n)
+	}
+}
+
+func TestDecodeStreamError(t *testing.T) {
+	t.Parallel()
+	errBoom := errors.New("boom")
+	err := DecodeStream(strings.NewReader(twoPages), StreamOptions{},
+		func(p Page) error { return errBoom })
+	if !errors.Is(err, errBoom) {
+		t.Errorf("DecodeStream(_)=%v, want %v", err, errBoom)
+	}
+}
+
+// rotatedPage is pdf2txt output of a portrait page with a rotation of 90
+// degrees: the page bounding box is landscape, and the coordinates are those
+// of the page as shown.
+const rotatedPage = `<?xml version="1.0" encoding="utf-8" ?>
+<pages>
+<page id="1" bbox="0.000,0.000,792.000,612.000" rotate="90">
+<textbox id="0" bbox="36.000,560.000,68.000,568.000">
+<textline bbox="36.000,560.000,68.000,568.000">
+<text font="ArialMT" bbox="36.000,560.000,40.000,568.000" size="8.000">N</text>
+<text font="ArialMT" bbox="40.000,560.000,44.000,568.000" size="8.000">e</text>
+<text font="ArialMT" bbox="44.000,560.000,48.000,568.000" size="8.000">t</text>
+<text> </text>
+<text font="ArialMT" bbox="52.000,560.000,56.000,568.000" size="8.000">P</text>
+<text font="ArialMT" bbox="56.000,560.000,60.000,568.000" size="8.000">a</text>
+<text font="ArialMT" bbox="60.000,560.000,64.000,568.000" size="8.000">y</text>
+<text>
+</text>
+</textline>
+</textbox>
+<textbox id="1" bbox="700.000,560.000,732.000,568.000">
+<textline bbox="700.000,560.000,732.000,568.000">
+<text font="ArialMT" bbox="700.000,560.000,704.000,568.000" size="8.000">1</text>
+<text font="ArialMT" bbox="704.000,560.000,708.000,568.000" size="8.000">0</text>
+<text font="ArialMT" bbox="708.000,560.000,712.000,568.000" size="8.000">0</text>
+<text font="ArialMT" bbox="712.000,560.000,716.000,568.000" size="8.000">.</text>
+<text font="ArialMT" bbox="716.000,560.000,720.000,568.000" size="8.000">0</text>
+<text font="ArialMT" bbox="720.000,560.000,724.000,568.000" size="8.000">0</text>
+<text>
+</text>
+</textline>
+</textbox>
+</page>
+</pages>
+`
+
+func TestDecodeRotated(t *testing.T) {
+	t.Parallel()
+	check := func(p Page) {
+		t.Helper()
+		if p.BBox.Right != 792 || p.BBox.Top != 612 {
+			t.Errorf("page bbox changed: %v", p.BBox)
+		}
+		tls := Textlines(p)
+		l, err := FindOneTL(tls, "Net Pay")
+		if err != nil {
+			t.Fatalf("FindOneTL: unexpected error: %v", err)
+		}
+		if want := (BBox{Left: 36, Right: 68, Bottom: 560, Top: 568}); l.BBox != want {
+			t.Errorf("Net Pay moved: %v, want: %v", l.BBox, want)
+		}
+		actual := TextOf(FindInBBox(tls, l.BBox.RightOf()))
+		if diff := cmp.Diff([]string{"100.00"}, actual); diff != "" {
+			t.Errorf("FindInBBox(_)=%v, want: [100.00]\ndiff:\n%v", actual, diff)
+		}
+	}
+	p, err := Decode(strings.NewReader(rotatedPage))
+	if err != nil {
+		t.Fatalf("Decode: unexpected error: %v", err)
+	}
+	check(p.Pages[0])
+	err = DecodeStream(strings.NewReader(rotatedPage), StreamOptions{}, func(p Page) error {
+		check(p)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("DecodeStream: unexpected error: %v", err)
+	}
+}
@@ -14,6 +14,18 @@ type Textline struct {
 	Texts []Text `xml:"text,omitempty"`
 }
 
+// At returns a textline with the text set in an 8 point regular font, with
+// the bottom left corner at the given position and 4 points per character.
+// It builds the pages of the tests.
+func At(text string, left, bottom float64) Textline {
+	var tl Textline
+	for _, c := range text {
+		tl.Texts = append(tl.Texts, Text{T: string(c), Font: "ArialMT", Size: 8})
+	}
+	tl.BBox = BBox{Left: left, Right: left + 4*float64(len(text)), Bottom: bottom, Top: bottom + 8}
+	return tl
+}
+
 // SortLeft sorts textline in a nonincreasing order of left boundaries.
 func SortLeft(tl []Textline) []Textline {
 	sort.Slice(tl, func(i, j int) bool {
@@ -45,6 +57,62 @@ func (t Textline) String() string {
 	return fmt.Sprintf("<%q BBox:%+v>", t.Text(), t.BBox)
 }
 
+// Font returns the dominant font of the textline, that is, the font that most
+// of its characters are set in.  Returns "" if no font is known.
+func (t Textline) Font() string {
+	var fonts []string
+	for _, tx := range t.Texts {
+		if tx.Font != "" {
+			fonts = append(fonts, tx.Font)
+		}
+	}
+	return dominant(fonts, "")
+}
+
+// Size returns the dominant font size of the textline, that is, the size that
+// most of its characters are set in.  Returns 0 if no size is known.
+func (t Textline) Size() float64 {
+	var sizes []float64
+	for _, tx := range t.Texts {
+		if tx.Size != 0 {
+			sizes = append(sizes, tx.Size)
+		}
+	}
+	return dominant(sizes, 0)
+}
+
+// Bold returns true if the dominant font of the textline is a bold font.  The
+// fonts do not say this directly, so this is guessed from the font name, e.g.
+// "Arial-BoldMT".
+func (t Textline) Bold() bool {
+	f := strings.ToLower(t.Font())
+	return strings.Contains(f, "bold") ||
+		strings.Contains(f, "black") ||
+		strings.Contains(f, "heavy")
+}
+
+// Italic returns true if the dominant font of the textline is an italic font.
+// This is guessed from the font name, e.g. "Arial-ItalicMT".
+func (t Textline) Italic() bool {
+	f := strings.ToLower(t.Font())
+	return strings.Contains(f, "italic") ||
+		strings.Contains(f, "oblique")
+}
+
+// dominant returns the most common value in vs, or def if vs is empty.  Of the
+// equally common values, the one that got there first wins.
+func dominant[T comparable](vs []T, def T) T {
+	counts := map[T]int{}
+	r, max := def, 0
+	for _, v := range vs {
+		counts[v]++
+		if counts[v] > max {
+			r, max = v, counts[v]
+		}
+	}
+	return r
+}
+
 // Text returns the text contained in this text line.
 func (t Textline) Text() string {
 	var b strings.Builder
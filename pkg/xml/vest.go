@@ -0,0 +1,75 @@
+package xml
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/filmil/fintools-public/pkg/tx"
+	"github.com/pkg/errors"
+)
+
+// The labels of the stock vest details, which some paystubs with stock unit
+// income list.
+var (
+	vestDateLabels     = []string{"Vest Date", "Release Date"}
+	vestSymbolLabels   = []string{"Symbol", "Ticker"}
+	vestSharesLabels   = []string{"Shares Vested", "Units Vested", "Shares Released"}
+	vestWithheldLabels = []string{"Shares Withheld", "Units Withheld"}
+	vestFMVLabels      = []string{"Vest FMV", "Fair Market Value", "Release Price"}
+)
+
+// parseShares parses a share count like "1,234.567".
+func parseShares(s string) (float64, error) {
+	f, err := strconv.ParseFloat(strings.ReplaceAll(strings.TrimSpace(s), ",", ""), 64)
+	if err != nil {
+		return 0, errors.Wrapf(err, "not a share count: %q", s)
+	}
+	return f, nil
+}
+
+// parseVest adds the stock vest to t, if the paystub lists one.  The vested
+// shares and the FMV must both be there, the other fields are optional.
+func parseVest(tls []Textline, t *tx.Transaction) error {
+	var v tx.Vest
+	found := false
+	for _, f := range []struct {
+		labels []string
+		v      *float64
+	}{
+		{vestSharesLabels, &v.Shares},
+		{vestWithheldLabels, &v.WithheldShares},
+	} {
+		s, ok, err := headerValue(tls, f.labels)
+		if err != nil {
+			return errors.Wrapf(err, "while parsing %v", f.labels[0])
+		}
+		if !ok {
+			continue
+		}
+		if *f.v, err = parseShares(s); err != nil {
+			return errors.Wrapf(err, "while parsing %v", f.labels[0])
+		}
+		found = true
+	}
+	fmv, ok, err := headerValue(tls, vestFMVLabels)
+	if err != nil {
+		return errors.Wrapf(err, "while parsing vest FMV")
+	}
+	if !found && !ok {
+		return nil
+	}
+	if v.Shares == 0 || !ok {
+		return errors.Errorf("vest needs both %q and %q", vestSharesLabels[0], vestFMVLabels[0])
+	}
+	if v.FMV, err = parseAmount(fmv); err != nil {
+		return errors.Wrapf(err, "while parsing vest FMV")
+	}
+	if v.Date, err = headerDate(tls, vestDateLabels); err != nil {
+		return errors.Wrapf(err, "while parsing vest date")
+	}
+	if v.Symbol, _, err = headerValue(tls, vestSymbolLabels); err != nil {
+		return errors.Wrapf(err, "while parsing vest symbol")
+	}
+	t.Vests = append(t.Vests, v)
+	return nil
+}
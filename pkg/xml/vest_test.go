@@ -0,0 +1,67 @@
+package xml
+
+import (
+	"testing"
+	"time"
+
+	"github.com/filmil/fintools-public/pkg/tx"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseVest(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name     string
+		tls      []Textline
+		expected []tx.Vest
+		err      bool
+	}{
+		{
+			name: "nothing",
+		},
+		{
+			name: "vest",
+			tls: []Textline{
+				At("Vest Date", 10, 100), At("01/25/2020", 150, 100),
+				At("Symbol", 10, 90), At("GOOG", 150, 90),
+				At("Shares Vested", 10, 80), At("1,010.5", 150, 80),
+				At("Shares Withheld", 10, 70), At("404", 150, 70),
+				At("Vest FMV", 10, 60), At("$1,450.50", 150, 60),
+			},
+			expected: []tx.Vest{{
+				Date:           tx.DateOnly(time.Date(2020, 1, 25, 0, 0, 0, 0, time.UTC)),
+				Symbol:         "GOOG",
+				Shares:         1010.5,
+				WithheldShares: 404,
+				FMV:            1450.5,
+			}},
+		},
+		{
+			name: "no FMV",
+			tls: []Textline{
+				At("Shares Vested", 10, 80), At("10", 150, 80),
+			},
+			err: true,
+		},
+	}
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			var actual tx.Transaction
+			err := parseVest(test.tls, &actual)
+			if test.err {
+				if err == nil {
+					t.Errorf("parseVest: want error, got: %v", actual.Vests)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseVest: unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(test.expected, actual.Vests, cmp.Comparer(tx.DateOnly.Equal)); diff != "" {
+				t.Errorf("parseVest(_)=%v, want: %v\ndiff:\n%v", actual.Vests, test.expected, diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,153 @@
+package xml
+
+import (
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/filmil/fintools-public/pkg/tx"
+	"github.com/golang/glog"
+	"github.com/pkg/errors"
+)
+
+// Withholding settings.
+//
+// Paystubs list the W-4 settings either as a table with a column per
+// jurisdiction:
+//
+//	                        Federal   State
+//	Filing Status           Single    Single
+//	Allowances              0         1
+//	Additional Withholding  0.00      0.00
+//
+// or with one label per setting, such as "Federal Filing Status".  A table
+// without the column headers has the federal settings only.
+
+// The row labels of the withholding settings.
+var (
+	filingStatusLabels = []string{"Filing Status", "Marital Status"}
+	allowancesLabels   = []string{"Allowances", "Exemptions", "Exemptions/Allowances"}
+	extraLabels        = []string{"Additional Withholding", "Extra Withholding", "Additional Amount"}
+)
+
+// w4Column finds the values of a jurisdiction, such as "Federal".
+type w4Column struct {
+	tls  []Textline
+	name string
+	// header is the column header, and other is the header of the other
+	// column, if any.
+	header, other *Textline
+	// single is set for a table with a single column and no column headers,
+	// whose values are right of the row labels.
+	single bool
+}
+
+// value returns the value of the setting with one of the row labels, or ""
+// if there is none.
+func (c w4Column) value(labels []string) (string, error) {
+	for _, l := range labels {
+		if v, ok, err := headerValue(c.tls, []string{c.name + " " + l}); ok || err != nil {
+			return v, err
+		}
+	}
+	if c.header == nil && !c.single {
+		return "", nil
+	}
+	for _, l := range labels {
+		tl, err := FindOneTL(c.tls, l)
+		if err != nil {
+			continue
+		}
+		for _, v := range SortLeft(FindInBBox(c.tls, tl.BBox.RightOf())) {
+			if c.single || c.closest(v) {
+				return v.Text(), nil
+			}
+		}
+		return "", nil
+	}
+	return "", nil
+}
+
+// closest returns true if v is closer to the column header than to the other
+// one.
+func (c w4Column) closest(v Textline) bool {
+	center := func(b BBox) float64 { return (b.Left + b.Right) / 2 }
+	d := math.Abs(center(v.BBox) - center(c.header.BBox))
+	if d > c.header.BBox.Right-c.header.BBox.Left+v.BBox.Right-v.BBox.Left {
+		// Too far to be in the column at all.
+		return false
+	}
+	return c.other == nil || d < math.Abs(center(v.BBox)-center(c.other.BBox))
+}
+
+// parse fills in the settings of the column.  The settings are optional, so
+// a value that does not parse is only warned about, and left zero.
+func (c w4Column) parse(status *string, allowances *int, extra *tx.USD) error {
+	var err error
+	if *status, err = c.value(filingStatusLabels); err != nil {
+		return errors.Wrapf(err, "while parsing %v filing status", c.name)
+	}
+	v, err := c.value(allowancesLabels)
+	if err != nil {
+		return errors.Wrapf(err, "while parsing %v allowances", c.name)
+	}
+	if v != "" {
+		if *allowances, err = strconv.Atoi(strings.TrimSpace(v)); err != nil {
+			glog.Warningf("%v allowances: ignored: %v", c.name, err)
+			*allowances = 0
+		}
+	}
+	if v, err = c.value(extraLabels); err != nil {
+		return errors.Wrapf(err, "while parsing %v additional withholding", c.name)
+	}
+	if v != "" {
+		if *extra, err = parseAmount(v); err != nil {
+			glog.Warningf("%v additional withholding: ignored: %v", c.name, err)
+			*extra = 0
+		}
+	}
+	return nil
+}
+
+// columnHeader returns the header of the column name of the table with the
+// top row label anchor: the closest one above the row and right of the label.
+// The same text may be elsewhere on the paystub, e.g. from a wrapped
+// "Federal Income Tax" line item.
+func columnHeader(tls []Textline, name string, anchor Textline) *Textline {
+	var r *Textline
+	for _, tl := range MatchPredicate(tls, BindText(name, MatchingText)) {
+		tl := tl
+		if tl.BBox.Bottom < anchor.BBox.Top || tl.BBox.Left < anchor.BBox.Right {
+			continue
+		}
+		if r == nil || tl.BBox.Bottom < r.BBox.Bottom {
+			r = &tl
+		}
+	}
+	return r
+}
+
+// parseW4 fills in the withholding settings of t, if the paystub lists them.
+func parseW4(tls []Textline, t *tx.Transaction) error {
+	var federal, state *Textline
+	var labels []string
+	labels = append(labels, filingStatusLabels...)
+	labels = append(labels, allowancesLabels...)
+	labels = append(labels, extraLabels...)
+	var rows []Textline
+	for _, l := range labels {
+		rows = append(rows, MatchPredicate(tls, BindText(l, MatchingText))...)
+	}
+	if len(rows) > 0 {
+		anchor := SortTop(rows)[0]
+		federal, state = columnHeader(tls, "Federal", anchor), columnHeader(tls, "State", anchor)
+	}
+	w := &t.W4
+	single := len(rows) > 0 && federal == nil && state == nil
+	if err := (w4Column{tls, "Federal", federal, state, single}).parse(
+		&w.FederalFilingStatus, &w.FederalAllowances, &w.FederalExtra); err != nil {
+		return err
+	}
+	return (w4Column{tls, "State", state, federal, false}).parse(
+		&w.StateFilingStatus, &w.StateAllowances, &w.StateExtra)
+}
@@ -0,0 +1,90 @@
+package xml
+
+import (
+	"testing"
+
+	"github.com/filmil/fintools-public/pkg/tx"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseW4(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name     string
+		tls      []Textline
+		expected tx.W4
+	}{
+		{
+			name: "nothing",
+		},
+		{
+			name: "table",
+			tls: []Textline{
+				// Same text as the column header, but elsewhere.
+				At("Federal", 10, 300),
+				At("Federal", 150, 110), At("State", 250, 110),
+				At("Marital Status", 10, 100), At("Married", 150, 100), At("Single", 250, 100),
+				At("Exemptions", 10, 90), At("2", 150, 90), At("1", 250, 90),
+				At("Additional Withholding", 10, 80), At("50.00", 150, 80), At("0.00", 250, 80),
+			},
+			expected: tx.W4{
+				FederalFilingStatus: "Married",
+				FederalAllowances:   2,
+				FederalExtra:        50,
+				StateFilingStatus:   "Single",
+				StateAllowances:     1,
+			},
+		},
+		{
+			name: "labels",
+			tls: []Textline{
+				At("Federal Filing Status", 10, 100), At("Single", 150, 100),
+				At("State Filing Status", 10, 90), At("Head of Household", 150, 90),
+				At("State Additional Withholding", 10, 80), At("$10.00", 150, 80),
+			},
+			expected: tx.W4{
+				FederalFilingStatus: "Single",
+				StateFilingStatus:   "Head of Household",
+				StateExtra:          10,
+			},
+		},
+		{
+			name: "single column",
+			tls: []Textline{
+				At("Filing Status", 10, 100), At("Married", 150, 100),
+				At("Allowances", 10, 90), At("3", 150, 90),
+				At("Additional Withholding", 10, 80), At("$25.00", 150, 80),
+			},
+			expected: tx.W4{
+				FederalFilingStatus: "Married",
+				FederalAllowances:   3,
+				FederalExtra:        25,
+			},
+		},
+		{
+			name: "unparsable values are left zero",
+			tls: []Textline{
+				At("Federal", 150, 110),
+				At("Filing Status", 10, 100), At("Single", 150, 100),
+				At("Allowances", 10, 90), At("N/A", 150, 90),
+				At("Additional Withholding", 10, 80), At("see W-4", 150, 80),
+			},
+			expected: tx.W4{
+				FederalFilingStatus: "Single",
+			},
+		},
+	}
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			var actual tx.Transaction
+			if err := parseW4(test.tls, &actual); err != nil {
+				t.Fatalf("parseW4: unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(test.expected, actual.W4); diff != "" {
+				t.Errorf("parseW4(_)=%+v, want: %+v\ndiff:\n%v", actual.W4, test.expected, diff)
+			}
+		})
+	}
+}
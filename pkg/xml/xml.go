@@ -6,6 +6,8 @@ import (
 	goxml "encoding/xml"
 	"fmt"
 	"io"
+	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
 	"time"
@@ -17,6 +19,9 @@ import (
 
 const eps = 1e-6
 
+// everywhere is a bounding box that contains everything.
+var everywhere = BBox{}.ExtendLeft().ExtendRight().ExtendTop().ExtendBottom()
+
 // USDate parses a US format date from the date string.
 func USDate(s string) (tx.DateOnly, error) {
 	d, err := time.Parse("01/02/2006", s)
@@ -89,9 +94,41 @@ func storeAmounts(textCol, amountCol []Textline, setter func(string, tx.USD) err
 	return nil
 }
 
+// Options modify how a Paystub is converted into a Transaction.
+type Options struct {
+	// Lenient, if set, records the line items with unknown labels in
+	// Transaction.Unknown instead of failing the conversion.
+	Lenient bool
+	// Stream, if set, makes ReadFile decode only the first page of the
+	// paystub, without the per-character detail, see ParseStream.  It only
+	// works with pdfminer input.
+	Stream bool
+}
+
+// setter wraps the setter function so that in lenient mode the labels it does
+// not know about are recorded as unknown in the given section of t.
+func (o Options) setter(t *tx.Transaction, section string, setter func(string, tx.USD) error) func(string, tx.USD) error {
+	if !o.Lenient {
+		return setter
+	}
+	return func(s string, amount tx.USD) error {
+		if err := setter(s, amount); err != nil {
+			glog.Warningf("unknown label in %v: %q: %v", section, s, amount)
+			t.UnknownByName(section, s, amount)
+		}
+		return nil
+	}
+}
+
 // Convert turns a Paystub parsed XML into a Transaction.
-// TODO(filmil): This function is horrifying. Refactor.
 func Convert(p Paystub) (tx.Transaction, error) {
+	return ConvertWithOptions(p, Options{})
+}
+
+// ConvertWithOptions turns a Paystub parsed XML into a Transaction, using the
+// supplied options.  The sections that the paystub does not have are
+// recorded in Transaction.Missing.
+func ConvertWithOptions(p Paystub, o Options) (tx.Transaction, error) {
 	var t tx.Transaction
 
 	tls := Textlines(p.Pages[0])
@@ -102,45 +139,49 @@ func Convert(p Paystub) (tx.Transaction, error) {
 		return t, errors.Wrapf(err, "while finding date")
 	}
 
-	earningsTl, err := FindOneTL(tls, "Earnings")
+	earningsTl, err := findHeader(tls, "Earnings", everywhere)
 	if err != nil {
 		return t, errors.Wrapf(err, "could not find earnings")
 	}
 
 	// Find the line containing "Taxes" which is below the "earnings" label.
-	taxesTl, err := FindOneTLInBBox(tls, "Taxes", earningsTl.BBox.ExtendBottom())
+	taxesB := everywhere
+	if earningsTl != nil {
+		taxesB = earningsTl.BBox.ExtendBottom()
+	}
+	taxesTl, err := findHeader(tls, "Taxes", taxesB)
 	if err != nil {
 		return t, errors.Wrapf(err, "could not find taxes")
 	}
 
-	deductionsTl, err := FindOneTLInBBox(tls, "Deductions", taxesTl.BBox.ExtendTop().ExtendRight())
+	deductionsB := everywhere
+	if taxesTl != nil {
+		deductionsB = taxesTl.BBox.ExtendTop().ExtendRight()
+	}
+	deductionsTl, err := findHeader(tls, "Deductions", deductionsB)
 	if err != nil {
 		return t, errors.Wrapf(err, "could not find deductions")
 	}
 
-	paidTimeOff, err := FindOneTL(tls, "Paid Time Off")
+	paidTimeOff, err := findHeader(tls, "Paid Time Off", everywhere)
 	if err != nil {
 		return t, errors.Wrapf(err, "could not find paid time off")
 	}
-
-	// Next make boxes
-	// Earnings box
-	earningsB := earningsTl.BBox.ExtendRight().ExtendBottom()
-	earningsB.Right = deductionsTl.BBox.Left // Limit from the left
-	earningsB.Bottom = taxesTl.BBox.Top + eps
-
-	// Deductions box
-	deductionsB := deductionsTl.BBox.ExtendRight().ExtendBottom()
-	deductionsB.Bottom = taxesTl.BBox.Top + eps
-
-	// Taxes box
-	taxesB := taxesTl.BBox.ExtendRight().ExtendBottom()
-	taxesB.Bottom = paidTimeOff.BBox.Top + eps
+	headers := []*Textline{earningsTl, taxesTl, deductionsTl, paidTimeOff}
+	// Other headers are not parsed, but end the sections above them.
+	for _, h := range otherHeaders {
+		tl, err := findHeader(tls, h, everywhere)
+		if err != nil {
+			return t, errors.Wrapf(err, "could not find %v", h)
+		}
+		headers = append(headers, tl)
+	}
 
 	// Parse from the "Pay Statement" box.
 	// Now try parsing some stuff out.
-	rightOfPayDate := payDateTxt.BBox.RightOf()
-	payDate, err := OneTextline(SortLeft(FindInBBox(tls, rightOfPayDate)))
+	// The pay date and the document number identify the paystub, so unlike
+	// the optional header fields, exactly one value must be right of them.
+	payDate, err := OneTextline(SortLeft(FindInBBox(tls, payDateTxt.BBox.RightOf())))
 	if err != nil {
 		return t, errors.Wrapf(err, "could not find date in %v", payDate)
 	}
@@ -153,65 +194,109 @@ func Convert(p Paystub) (tx.Transaction, error) {
 	if err != nil {
 		return t, errors.Wrapf(err, "while looking for document")
 	}
-	rightOfDocumentTl := documentTl.BBox.RightOf()
-	docNumTl, err := OneTextline(SortLeft(FindInBBox(tls, rightOfDocumentTl)))
+	docNumTl, err := OneTextline(SortLeft(FindInBBox(tls, documentTl.BBox.RightOf())))
 	if err != nil {
 		return t, errors.Wrapf(err, "no docnumTL")
 	}
 	t.DocNum = docNumTl.Text()
 
-	// Parse from the "Earnings" box.
+	parseHeader(tls, &t)
+	if err := parseW4(tls, &t); err != nil {
+		return t, errors.Wrapf(err, "while parsing the withholding settings")
+	}
+	if err := parseVest(tls, &t); err != nil {
+		return t, errors.Wrapf(err, "while parsing the stock vest")
+	}
+
+	// Parse net pay.  Net pay amount is right of the label "Net Pay" which is
+	// located above the fixed "Earnings" label.
+	netPayB := everywhere
+	if earningsTl != nil {
+		netPayB = earningsTl.BBox.ExtendTop().ExtendRight()
+	}
+	netPayTl, err := FindOneTLInBBox(tls, "Net Pay", netPayB)
+	if err != nil {
+		return t, errors.Wrapf(err, "while finding Net Pay in the upper right corner")
+	}
+	netPayAmtTl, err := OneTextline(FindInBBox(tls, netPayTl.BBox.RightOf()))
+	if err != nil {
+		return t, errors.Wrapf(err, "could not find amount right of 'Net pay'")
+	}
+	t.NetPay, err = parseAmount(netPayAmtTl.Text())
+	if err != nil {
+		return t, errors.Wrapf(err, "could not set Net Pay")
+	}
+
+	if earningsTl == nil {
+		t.Missing = append(t.Missing, tx.SectionEarnings)
+	} else if err := convertEarnings(tls, sectionBox(earningsTl, headers...), &t, o); err != nil {
+		return t, err
+	}
+	if deductionsTl == nil {
+		t.Missing = append(t.Missing, tx.SectionDeductions, tx.SectionEmployer)
+	} else if err := convertDeductions(tls, sectionBox(deductionsTl, headers...), &t, o); err != nil {
+		return t, err
+	}
+	if taxesTl == nil {
+		t.Missing = append(t.Missing, tx.SectionTaxes)
+	} else if err := convertTaxes(tls, sectionBox(taxesTl, headers...), &t, o); err != nil {
+		return t, err
+	}
+	if len(t.Missing) > 0 {
+		sortSections(t.Missing)
+		glog.Warningf("paystub %v: missing sections: %v", t.DocNum, t.Missing)
+	}
+
+	if err := parseKind(tls, headerBox(headers...), &t); err != nil {
+		return t, errors.Wrapf(err, "while parsing the paystub kind")
+	}
+	return t, nil
+}
+
+// convertEarnings parses the earnings in the box earningsB.
+func convertEarnings(tls []Textline, earningsB BBox, t *tx.Transaction, o Options) error {
 	// Narrow only to the earnings textlines.
 	earningsTls := FindInBBox(tls, earningsB)
 
-	totalHoursWorkedTl, err := FindOneTLPrefix(tls, "Total Hours Worked")
-	if err != nil {
-		return t, errors.Wrapf(err, "could not find Total Hours Worked")
+	// The earnings end at "Total Hours Worked", if the paystub has it.
+	totalHoursWorkedTl, err := FindOneTLPrefix(earningsTls, "Total Hours Worked")
+	if err == nil {
+		earningsB.Bottom = totalHoursWorkedTl.BBox.Top + eps
+		earningsTls = FindInBBox(tls, earningsB)
 	}
-	earningsB.Bottom = totalHoursWorkedTl.BBox.Top + eps
 
 	earningsPayTypeTl, err := FindOneTL(earningsTls, "Pay Type")
 	if err != nil {
-		return t, errors.Wrapf(err, "could not find pay type in earnings box")
+		return errors.Wrapf(err, "could not find pay type in earnings box")
 	}
 
 	// Get a box that extends from pay type down to total hours worked
 	payTypesBox := earningsPayTypeTl.BBox
 	payTypesBox.Top = payTypesBox.Bottom - eps
-	payTypesBox.Bottom = totalHoursWorkedTl.BBox.Top + eps
+	payTypesBox.Bottom = earningsB.Bottom
 
 	currentTl, err := FindOneTL(earningsTls, "Current")
 	if err != nil {
-		return t, errors.Wrapf(err, "could not find current in earnings box")
+		return errors.Wrapf(err, "could not find current in earnings box")
 	}
 
 	// Get a box that extends from Current down to total hours worked
 	currentBox := currentTl.BBox
 	currentBox.Top = currentBox.Bottom - eps
-	currentBox.Bottom = totalHoursWorkedTl.BBox.Top + eps
+	currentBox.Bottom = earningsB.Bottom
 
 	payTypesTls := SortTop(FindInBBox(earningsTls, payTypesBox))
 	currentTls := SortTop(FindInBBox(earningsTls, currentBox))
-	if err := storeAmounts(payTypesTls, currentTls, t.IncomeByName); err != nil {
-		return t, errors.Wrapf(err, "while setting income")
-	}
-
-	// Parse net pay.  Net pay amount is right of the label "Net Pay" which is
-	// located above the fixed "Earnings" label.
-	netPayTl, err := FindOneTLInBBox(tls, "Net Pay", earningsTl.BBox.ExtendTop().ExtendRight())
-	if err != nil {
-		return t, errors.Wrapf(err, "while finding Net Pay in the upper right corner")
-	}
-	netPayAmtTl, err := OneTextline(FindInBBox(tls, netPayTl.BBox.RightOf()))
-	if err != nil {
-		return t, errors.Wrapf(err, "could not find amount right of 'Net pay'")
-	}
-	t.NetPay, err = parseAmount(netPayAmtTl.Text())
-	if err != nil {
-		return t, errors.Wrapf(err, "could not set Net Pay")
+	if err := storeAmounts(payTypesTls, currentTls,
+		o.setter(t, tx.SectionEarnings, t.IncomeByName)); err != nil {
+		return errors.Wrapf(err, "while setting income")
 	}
+	return nil
+}
 
-	// Parse deductions.  Uses deductionB which was established way up.
+// convertDeductions parses the employee and the employer deductions in the
+// box deductionsB.
+func convertDeductions(tls []Textline, deductionsB BBox, t *tx.Transaction, o Options) error {
 	// For deduction name, we look for strings under a fixed label "Deduction".
 	// For contribution, we look for strings under label "Current" which is
 	// in itself under label "Employee" in the deduction box.
@@ -222,21 +307,21 @@ func Convert(p Paystub) (tx.Transaction, error) {
 	// Find the textline "Deduction"
 	dedTl, err := FindOneTL(dedsTls, "Deduction")
 	if err != nil {
-		return t, errors.Wrapf(err, "could not find Deduction in Deductions")
+		return errors.Wrapf(err, "could not find Deduction in Deductions")
 	}
 
 	// Parse Deductions->Employee->Current
 	// Find the textline "Employee"
 	employeeTl, err := FindOneTL(dedsTls, "Employee")
 	if err != nil {
-		return t, errors.Wrapf(err, "could not find Employee in Deductions")
+		return errors.Wrapf(err, "could not find Employee in Deductions")
 	}
 	// Find the textline "Current" below Employee.
 	employeeCurrentTl, err := FindOneTLInBBox(dedsTls,
 		"Current",
 		employeeTl.BBox.ExtendDownTo(deductionsB.Bottom).Below(employeeTl.BBox))
 	if err != nil {
-		return t, errors.Wrapf(err, "could not find Current below Employee in Deductions")
+		return errors.Wrapf(err, "could not find Current below Employee in Deductions")
 	}
 
 	// Isolate the column where we expect the deduction texts to be, and get
@@ -249,14 +334,20 @@ func Convert(p Paystub) (tx.Transaction, error) {
 		ExtendDownTo(deductionsB.Bottom).
 		Below(employeeCurrentTl.BBox)
 	dedAmountColTls := SortTop(FindInBBox(dedsTls, dedsAmountsCol))
-	if err := storeAmounts(dedColTls, dedAmountColTls, t.ExpenseByName); err != nil {
-		return t, errors.Wrapf(err, "while parsing expenses")
+	if err := storeAmounts(dedColTls, dedAmountColTls,
+		o.setter(t, tx.SectionDeductions, t.ExpenseByName)); err != nil {
+		return errors.Wrapf(err, "while parsing expenses")
 	}
 
-	// Parse Deductions->Employer->Current
+	// Parse Deductions->Employer->Current.  Some paystubs have no employer
+	// column.
 	employerTl, err := FindOneTL(dedsTls, "Employer")
 	if err != nil {
-		return t, errors.Wrapf(err, "could not find Employer in Deductions")
+		if len(MatchPredicate(dedsTls, BindText("Employer", MatchingText))) == 0 {
+			t.Missing = append(t.Missing, tx.SectionEmployer)
+			return nil
+		}
+		return errors.Wrapf(err, "could not find Employer in Deductions")
 	}
 
 	// Find the textline "Current" below Employer.  Sadly, that text is
@@ -267,7 +358,7 @@ func Convert(p Paystub) (tx.Transaction, error) {
 		BindText("Current", MatchingSuffix),
 		BindBBox(employerTlBBox, IntersectingBBoxTextline)))
 	if err != nil {
-		return t, errors.Wrapf(err, "could not find Current below Employer in Deductions")
+		return errors.Wrapf(err, "could not find Current below Employer in Deductions")
 	}
 
 	emplDedsAmountsCol := employerCurrentTl.BBox.
@@ -278,24 +369,28 @@ func Convert(p Paystub) (tx.Transaction, error) {
 			dedsTls,
 			BindBBox(emplDedsAmountsCol, IntersectingBBoxTextline),
 			BindBBox(employerTlBBox, IntersectingBBoxTextline)))
-	if err := storeAmounts(dedColTls, emplDedAmountColTls, t.EmployerExpenseByName); err != nil {
-		return t, errors.Wrapf(err, "while parsing employer deductions")
+	if err := storeAmounts(dedColTls, emplDedAmountColTls,
+		o.setter(t, tx.SectionEmployer, t.EmployerExpenseByName)); err != nil {
+		return errors.Wrapf(err, "while parsing employer deductions")
 	}
+	return nil
+}
 
-	// Parse taxes. Uses taxesB whihc was established way up.  For tax name,
-	// we look for strings under a fixed label "Tax" in this section. For the
-	// tax amounts, we look for the amounts under a fixed label "Current" in
-	// this section.
+// convertTaxes parses the taxes in the box taxesB.
+func convertTaxes(tls []Textline, taxesB BBox, t *tx.Transaction, o Options) error {
+	// For tax name, we look for strings under a fixed label "Tax" in this
+	// section. For the tax amounts, we look for the amounts under a fixed
+	// label "Current" in this section.
 
 	// Limit to the textlines in this section.
 	taxesTls := FindInBBox(tls, taxesB)
 	taxTl, err := FindOneTL(taxesTls, "Tax")
 	if err != nil {
-		return t, errors.Wrapf(err, "could not find Tax in Taxes box")
+		return errors.Wrapf(err, "could not find Tax in Taxes box")
 	}
 	taxCurrentTl, err := FindOneTL(taxesTls, "Current")
 	if err != nil {
-		return t, errors.Wrapf(err, "could not find Current in Taxes box")
+		return errors.Wrapf(err, "could not find Current in Taxes box")
 	}
 
 	// Find the column for the taxes labels.
@@ -305,30 +400,45 @@ func Convert(p Paystub) (tx.Transaction, error) {
 	taxColTls := SortTop(FindInBBox(taxesTls, taxColB))
 	taxAmountTls := SortTop(FindInBBox(taxesTls, taxAmountB))
 
-	if err := storeAmounts(taxColTls, taxAmountTls, t.ExpenseByName); err != nil {
-		return t, errors.Wrapf(err, "while setting tax expenses")
+	if err := storeAmounts(taxColTls, taxAmountTls,
+		o.setter(t, tx.SectionTaxes, t.ExpenseByName)); err != nil {
+		return errors.Wrapf(err, "while setting tax expenses")
 	}
-	return t, nil
+	return nil
+}
+
+// ParseAmount parses an amount as it is written on a paystub, such as
+// "$1,234.56" or "(10.00)".
+func ParseAmount(s string) (tx.USD, error) {
+	return parseAmount(s)
 }
 
 func parseAmount(s string) (tx.USD, error) {
 	glog.V(3).Infof("parseAmount(%v)", s)
-	r := strings.NewReplacer("$", "", ",", "", "(", "", ")", "")
-	t := r.Replace(s)
-	f, err := strconv.ParseFloat(t, 64)
+	s = strings.TrimSpace(s)
 	if len(s) == 0 {
 		return tx.USD(0), fmt.Errorf("Nothing to parse: %q", s)
 	}
 	// Negative amounts are denoted like so: ($10).  Makes no sense, but here
-	// we are.
-	if s[0] == '(' {
+	// we are.  Some payroll systems write $(10.00) or 10.00- instead.
+	neg := strings.HasSuffix(s, ")") && strings.Contains(s, "(")
+	if strings.HasSuffix(s, "-") {
+		neg = true
+		s = strings.TrimSuffix(s, "-")
+	}
+	r := strings.NewReplacer("$", "", ",", "", "(", "", ")", "")
+	t := r.Replace(s)
+	f, err := strconv.ParseFloat(t, 64)
+	if neg {
 		f = -f
 	}
 	return tx.USD(f), err
 
 }
 
-// Decode decodes the reader into Paystub data.
+// Decode decodes the reader into Paystub data.  pdf2txt applies the page
+// rotation, so the coordinates of a rotated page are those of the page as
+// shown, and the parsing code can use them as they are.
 func Decode(r io.Reader) (Paystub, error) {
 	var p Paystub
 	d := goxml.NewDecoder(r)
@@ -338,16 +448,87 @@ func Decode(r io.Reader) (Paystub, error) {
 	return p, nil
 }
 
+// Input formats understood by DecodeFormat.
+const (
+	// FormatPdfminer is the XML output of pdf2txt from pdfminer.
+	FormatPdfminer = "pdfminer"
+	// FormatPoppler is the XHTML output of `pdftotext -bbox-layout`.
+	FormatPoppler = "poppler"
+	// FormatHOCR is the hOCR output of an OCR engine such as tesseract.
+	FormatHOCR = "hocr"
+)
+
+// DecodeFormat decodes the reader in the given input format into Paystub data.
+func DecodeFormat(r io.Reader, format string) (Paystub, error) {
+	switch format {
+	case FormatPdfminer:
+		return Decode(r)
+	case FormatPoppler:
+		return DecodePoppler(r)
+	case FormatHOCR:
+		return DecodeHOCR(r)
+	}
+	return Paystub{}, errors.Errorf("unknown input format: %q", format)
+}
+
+// Encode writes the Paystub data into the writer, in the XML format that
+// pdf2txt produces, so that Decode can read it back.
+func Encode(w io.Writer, p Paystub) error {
+	if _, err := io.WriteString(w, goxml.Header); err != nil {
+		return errors.Wrapf(err, "while writing XML header")
+	}
+	e := goxml.NewEncoder(w)
+	e.Indent("", " ")
+	if err := e.Encode(p); err != nil {
+		return errors.Wrapf(err, "while writing XML")
+	}
+	if _, err := io.WriteString(w, "\n"); err != nil {
+		return errors.Wrapf(err, "while writing XML")
+	}
+	return nil
+}
+
 // Parse parses passed reader into a Paystub
 func Parse(r io.Reader) (tx.Transaction, error) {
+	return ParseWithOptions(r, Options{})
+}
+
+// ParseWithOptions parses passed reader into a Paystub, using the supplied
+// conversion options.
+func ParseWithOptions(r io.Reader, o Options) (tx.Transaction, error) {
 	var t tx.Transaction
 	p, err := Decode(r)
 	if err != nil {
 		return t, errors.Wrapf(err, "while decoding input to Paystub")
 	}
-	t, err = Convert(p)
+	t, err = ConvertWithOptions(p, o)
 	if err != nil {
 		return t, errors.Wrapf(err, "while converting Paystub to Transaction")
 	}
 	return t, nil
 }
+
+// ReadFile reads the transaction from the named file.  The file is a paystub
+// in the given input format, see DecodeFormat, or a transaction in JSON, see
+// tx.Read, if its name ends with ".json".
+func ReadFile(name, format string, o Options) (tx.Transaction, error) {
+	file, err := os.Open(name)
+	if err != nil {
+		return tx.Transaction{}, errors.Wrapf(err, "could not open file")
+	}
+	defer file.Close()
+	if strings.EqualFold(filepath.Ext(name), ".json") {
+		return tx.Read(file)
+	}
+	if o.Stream {
+		if format != FormatPdfminer {
+			return tx.Transaction{}, errors.Errorf("streaming only works with the %v input format, not %q", FormatPdfminer, format)
+		}
+		return ParseStream(file, o)
+	}
+	p, err := DecodeFormat(file, format)
+	if err != nil {
+		return tx.Transaction{}, errors.Wrapf(err, "while decoding input to Paystub")
+	}
+	return ConvertWithOptions(p, o)
+}
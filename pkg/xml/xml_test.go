@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/filmil/fintools-public/pkg/tx"
@@ -68,3 +69,30 @@ func TestParsingJSON(t *testing.T) {
 		})
 	}
 }
+
+func TestReadFileJSON(t *testing.T) {
+	t.Parallel()
+	name := filepath.Join(t.TempDir(), "paystub.JSON")
+	if err := os.WriteFile(name, []byte(`{"DocNum": "42", "NetPay": 1000}`), 0o600); err != nil {
+		t.Fatalf("WriteFile: unexpected: %v", err)
+	}
+	actual, err := ReadFile(name, "unknown", Options{})
+	if err != nil {
+		t.Fatalf("ReadFile(%q, _, _): unexpected: %v", name, err)
+	}
+	expected := tx.Transaction{DocNum: "42", NetPay: 1000}
+	if diff := cmp.Diff(expected, actual); diff != "" {
+		t.Errorf("ReadFile(%q, _, _)=%v, want: %v\ndiff:\n%v", name, actual, expected, diff)
+	}
+}
+
+func TestReadFileStreamFormat(t *testing.T) {
+	t.Parallel()
+	name := filepath.Join(t.TempDir(), "paystub.html")
+	if err := os.WriteFile(name, []byte(poppler), 0o600); err != nil {
+		t.Fatalf("WriteFile: unexpected: %v", err)
+	}
+	if _, err := ReadFile(name, FormatPoppler, Options{Stream: true}); err == nil {
+		t.Errorf("ReadFile(%q, %q, _): want error, got nil", name, FormatPoppler)
+	}
+}